@@ -19,21 +19,69 @@ func main() {
 
 	logger.Info("Starting gomw-gw", logger.Fields{
 		"listen_address":    cfg.Server.ListenAddress,
+		"admin_address":     cfg.Server.AdminListenAddress,
 		"on_connect_url":    cfg.Webhook.OnConnectURL,
 		"on_disconnect_url": cfg.Webhook.OnDisconnectURL,
+		"message_url":       cfg.Webhook.MessageURL,
+		"route_selection":   cfg.Webhook.RouteSelectionExpression,
+		"jwt_authorizer":    cfg.JWT.Enabled(),
+		"admin_auth":        len(cfg.Admin.APIKeys) > 0 || len(cfg.Admin.CertScopes) > 0,
+		"admin_mtls":        cfg.Admin.TLS.ClientCAFile != "",
+		"allowed_origins":   cfg.WebSocket.AllowedOrigins,
 	})
 
-	sessionManager := services.NewSessionManager()
-	webhookService := services.NewWebhookService(&cfg.Webhook, &cfg.Server)
+	metrics := services.NewMetrics()
+	sessionManager := services.NewSessionManager(&cfg.WebSocket)
+	channelManager := services.NewChannelManager(&cfg.WebSocket, sessionManager)
+	webhookService := services.NewWebhookService(&cfg.Webhook, &cfg.Server, sessionManager)
+	webhookService.OnSessionContextUpdated(sessionManager.RefreshUserIndex)
 
-	wsHandler := handlers.NewWebSocketHandler(&cfg.WebSocket, sessionManager, webhookService)
+	jwtAuthorizer, err := services.NewJWTAuthorizer(&cfg.JWT)
+	if err != nil {
+		logger.Fatal("JWT authorizer setup failed", logger.Fields{
+			"error": err.Error(),
+		})
+	}
+
+	if (cfg.Admin.TLS.ClientCAFile != "" || len(cfg.Admin.CertScopes) > 0) &&
+		(cfg.Server.AdminListenAddress == "" || !cfg.Admin.TLS.Enabled() || cfg.Admin.TLS.ClientCAFile == "") {
+		logger.Fatal("Admin client certificates require ADMIN_LISTEN_ADDR, ADMIN_TLS_CERT_FILE, ADMIN_TLS_KEY_FILE and ADMIN_CLIENT_CA_FILE", logger.Fields{})
+	}
+	if cfg.Admin.TLS.ClientCAFile != "" && len(cfg.Admin.CertScopes) == 0 {
+		logger.Fatal("ADMIN_CLIENT_CA_FILE requires ADMIN_CERT_SCOPES", logger.Fields{})
+	}
+
+	adminAuth, err := server.NewAdminAuthenticator(&cfg.Admin)
+	if err != nil {
+		logger.Fatal("Admin API authentication setup failed", logger.Fields{
+			"error": err.Error(),
+		})
+	}
+	if adminAuth == nil {
+		logger.Warn("Admin API authentication is disabled", logger.Fields{})
+	}
+
+	wsHandler := handlers.NewWebSocketHandler(&cfg.WebSocket, sessionManager, channelManager, webhookService, jwtAuthorizer, metrics)
 	msgHandler := handlers.NewMessageHandler(sessionManager)
-	infoHandler := handlers.NewInfoHandler(cfg, sessionManager)
+	infoHandler := handlers.NewInfoHandler(cfg, sessionManager, metrics)
+	connectionHandler := handlers.NewConnectionHandler(sessionManager, channelManager)
+	channelHandler := handlers.NewChannelHandler(channelManager)
+	userHandler := handlers.NewUserHandler(sessionManager)
 
-	router := server.NewRouter(wsHandler, msgHandler, infoHandler)
+	router := server.NewRouter(wsHandler, msgHandler, infoHandler, connectionHandler, channelHandler, userHandler, adminAuth)
 	router.SetupRoutes()
 
-	srv := server.NewServer(&cfg.Server, router.GetHandler())
+	var srv *server.Server
+	if cfg.Server.AdminListenAddress != "" {
+		srv = server.NewServer(&cfg.Server,
+			server.Listener{Name: "public", Address: cfg.Server.ListenAddress, TLS: &cfg.Server.TLS, Handler: router.PublicHandler()},
+			server.Listener{Name: "admin", Address: cfg.Server.AdminListenAddress, TLS: &cfg.Admin.TLS, Handler: router.AdminHandler()},
+		)
+	} else {
+		srv = server.NewServer(&cfg.Server,
+			server.Listener{Name: "public", Address: cfg.Server.ListenAddress, TLS: &cfg.Server.TLS, Handler: router.GetHandler()},
+		)
+	}
 
 	go func() {
 		if err := srv.Start(); err != nil {
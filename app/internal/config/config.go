@@ -1,7 +1,10 @@
 package config
 
 import (
+	"compress/flate"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -9,49 +12,309 @@ type Config struct {
 	Server    ServerConfig    `json:"server"`
 	Webhook   WebhookConfig   `json:"webhook"`
 	WebSocket WebSocketConfig `json:"websocket"`
+	JWT       JWTConfig       `json:"jwt"`
+	Admin     AdminConfig     `json:"admin"`
 }
 
 type ServerConfig struct {
-	ListenAddress string        `json:"listen_address"`
-	ReadTimeout   time.Duration `json:"read_timeout"`
-	WriteTimeout  time.Duration `json:"write_timeout"`
+	ListenAddress      string        `json:"listen_address"`
+	AdminListenAddress string        `json:"admin_listen_address"`
+	ReadTimeout        time.Duration `json:"read_timeout"`
+	WriteTimeout       time.Duration `json:"write_timeout"`
+	TLS                TLSConfig     `json:"tls"`
+}
+
+type TLSConfig struct {
+	CertFile       string        `json:"cert_file"`
+	KeyFile        string        `json:"key_file"`
+	MinVersion     string        `json:"min_version"`
+	CipherSuites   []string      `json:"cipher_suites"`
+	ReloadInterval time.Duration `json:"reload_interval"`
+	ClientCAFile   string        `json:"client_ca_file"`
+
+	RequireClientCert bool `json:"require_client_cert"`
 }
 
 type WebhookConfig struct {
 	OnConnectURL    string        `json:"on_connect_url"`
 	OnDisconnectURL string        `json:"on_disconnect_url"`
+	MessageURL      string        `json:"message_url"`
 	Timeout         time.Duration `json:"timeout"`
+
+	RouteSelectionExpression string            `json:"route_selection_expression"`
+	Routes                   map[string]string `json:"routes"`
+	TwoWayRoutes             []string          `json:"two_way_routes"`
+
+	SubprotocolRouteSelectionExpressions map[string]string `json:"subprotocol_route_selection_expressions"`
+
+	AuthorizerEnabled  bool          `json:"authorizer_enabled"`
+	AuthorizerTimeout  time.Duration `json:"authorizer_timeout"`
+	AuthorizerFailOpen bool          `json:"authorizer_fail_open"`
+
+	SubscribeAuthURL string `json:"subscribe_auth_url"`
+
+	OnConnectMaxInFlight int `json:"onconnect_max_in_flight"`
+
+	MessageQueueSize int `json:"message_queue_size"`
+}
+
+type JWTConfig struct {
+	Secret       string        `json:"-"`
+	JWKSFile     string        `json:"jwks_file"`
+	TokenSources []string      `json:"token_sources"`
+	Issuer       string        `json:"issuer"`
+	Audience     string        `json:"audience"`
+	Leeway       time.Duration `json:"leeway"`
+}
+
+type AdminConfig struct {
+	APIKeys     []string          `json:"-"`
+	HMACMaxSkew time.Duration     `json:"hmac_max_skew"`
+	CertScopes  map[string]string `json:"cert_scopes"`
+	TLS         TLSConfig         `json:"tls"`
 }
 
 type WebSocketConfig struct {
-	ReadBufferSize  int `json:"read_buffer_size"`
-	WriteBufferSize int `json:"write_buffer_size"`
-	CheckOrigin     bool `json:"check_origin"`
+	ReadBufferSize       int    `json:"read_buffer_size"`
+	WriteBufferSize      int    `json:"write_buffer_size"`
+	ControlFramesEnabled bool   `json:"control_frames_enabled"`
+	MaxSubscriptions     int    `json:"max_subscriptions"`
+	UserIDSource         string `json:"user_id_source"`
+
+	AllowedOrigins   []string `json:"allowed_origins"`
+	AllowEmptyOrigin bool     `json:"allow_empty_origin"`
+	TrustedProxies   []string `json:"trusted_proxies"`
+
+	Subprotocols []string `json:"subprotocols"`
+
+	CompressionEnabled bool `json:"compression_enabled"`
+	CompressionLevel   int  `json:"compression_level"`
+	CompressionMinSize int  `json:"compression_min_size"`
+
+	SendQueueSize  int           `json:"send_queue_size"`
+	WriteTimeout   time.Duration `json:"write_timeout"`
+	OverflowPolicy string        `json:"overflow_policy"`
+
+	PingInterval time.Duration `json:"ping_interval"`
+	PongWait     time.Duration `json:"pong_wait"`
+	IdleTimeout  time.Duration `json:"idle_timeout"`
+
+	MaxConnectionDuration       time.Duration `json:"max_connection_duration"`
+	MaxConnectionDurationJitter time.Duration `json:"max_connection_duration_jitter"`
+	ReconnectNotice             string        `json:"reconnect_notice"`
+	ReconnectGracePeriod        time.Duration `json:"reconnect_grace_period"`
+	MaxConnectionCloseCode      int           `json:"max_connection_close_code"`
+
+	MaxMessageSize int64   `json:"max_message_size"`
+	RateLimit      float64 `json:"rate_limit"`
+	RateBurst      int     `json:"rate_burst"`
+	LimitAction    string  `json:"limit_action"`
+
+	MaxConnections            int           `json:"max_connections"`
+	MaxConnectionsPerIP       int           `json:"max_connections_per_ip"`
+	ConnectionLimitRetryAfter time.Duration `json:"connection_limit_retry_after"`
+
+	HandshakeRateLimit      float64       `json:"handshake_rate_limit"`
+	HandshakeBurst          int           `json:"handshake_burst"`
+	HandshakeRateLimitPerIP float64       `json:"handshake_rate_limit_per_ip"`
+	HandshakeBurstPerIP     int           `json:"handshake_burst_per_ip"`
+	HandshakeRetryAfter     time.Duration `json:"handshake_retry_after"`
+	HandshakeRetryJitter    time.Duration `json:"handshake_retry_jitter"`
 }
 
 func LoadConfig() *Config {
+	adminAPIKeys := getEnvList("ADMIN_API_KEYS")
+
 	return &Config{
 		Server: ServerConfig{
-			ListenAddress: getEnvOrDefault("LISTEN_ADDR", ":8080"),
-			ReadTimeout:   5 * time.Second,
-			WriteTimeout:  10 * time.Second,
+			ListenAddress:      getEnvOrDefault("LISTEN_ADDR", ":8080"),
+			AdminListenAddress: os.Getenv("ADMIN_LISTEN_ADDR"),
+			ReadTimeout:        5 * time.Second,
+			WriteTimeout:       10 * time.Second,
+			TLS: TLSConfig{
+				CertFile:       os.Getenv("TLS_CERT_FILE"),
+				KeyFile:        os.Getenv("TLS_KEY_FILE"),
+				MinVersion:     getEnvOrDefault("TLS_MIN_VERSION", "1.2"),
+				CipherSuites:   getEnvList("TLS_CIPHER_SUITES"),
+				ReloadInterval: getEnvDuration("TLS_RELOAD_INTERVAL", 30*time.Second),
+			},
 		},
 		Webhook: WebhookConfig{
 			OnConnectURL:    os.Getenv("ONCONNECT_URL"),
 			OnDisconnectURL: os.Getenv("DISCONNECT_URL"),
+			MessageURL:      os.Getenv("MESSAGE_URL"),
 			Timeout:         5 * time.Second,
+
+			RouteSelectionExpression: os.Getenv("ROUTE_SELECTION_EXPRESSION"),
+			Routes:                   getEnvMap("ROUTES"),
+			TwoWayRoutes:             getEnvList("TWO_WAY_ROUTES"),
+
+			SubprotocolRouteSelectionExpressions: getEnvMap("SUBPROTOCOL_ROUTE_SELECTION_EXPRESSIONS"),
+
+			AuthorizerEnabled:  getEnvBool("ONCONNECT_AUTHORIZER", false),
+			AuthorizerTimeout:  getEnvDuration("ONCONNECT_AUTHORIZER_TIMEOUT", 3*time.Second),
+			AuthorizerFailOpen: getEnvBool("ONCONNECT_AUTHORIZER_FAIL_OPEN", false),
+
+			SubscribeAuthURL: os.Getenv("SUBSCRIBE_AUTH_URL"),
+
+			OnConnectMaxInFlight: getEnvInt("ONCONNECT_MAX_IN_FLIGHT", 0),
+
+			MessageQueueSize: getEnvInt("MESSAGE_QUEUE_SIZE", 64),
 		},
 		WebSocket: WebSocketConfig{
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
-			CheckOrigin:     true,
+			ReadBufferSize:       1024,
+			WriteBufferSize:      1024,
+			ControlFramesEnabled: getEnvBool("CONTROL_FRAMES_ENABLED", false),
+			MaxSubscriptions:     getEnvInt("MAX_SUBSCRIPTIONS_PER_CONNECTION", 100),
+			UserIDSource:         os.Getenv("USER_ID_SOURCE"),
+
+			AllowedOrigins:   getEnvList("ALLOWED_ORIGINS"),
+			AllowEmptyOrigin: getEnvBool("ALLOW_EMPTY_ORIGIN", true),
+			TrustedProxies:   getEnvList("TRUSTED_PROXIES"),
+
+			Subprotocols: getEnvList("WS_SUBPROTOCOLS"),
+
+			CompressionEnabled: getEnvBool("WS_COMPRESSION", false),
+			CompressionLevel:   getEnvInt("WS_COMPRESSION_LEVEL", flate.BestSpeed),
+			CompressionMinSize: getEnvInt("WS_COMPRESSION_MIN_SIZE", 512),
+
+			SendQueueSize:  getEnvInt("WS_SEND_QUEUE_SIZE", 256),
+			WriteTimeout:   getEnvDuration("WS_WRITE_TIMEOUT", 10*time.Second),
+			OverflowPolicy: getEnvOrDefault("WS_OVERFLOW_POLICY", "drop_newest"),
+
+			PingInterval: getEnvDuration("WS_PING_INTERVAL", 30*time.Second),
+			PongWait:     getEnvDuration("WS_PONG_WAIT", 60*time.Second),
+			IdleTimeout:  getEnvDuration("WS_IDLE_TIMEOUT", 0),
+
+			MaxConnectionDuration:       getEnvDuration("MAX_CONNECTION_DURATION", 0),
+			MaxConnectionDurationJitter: getEnvDuration("MAX_CONNECTION_DURATION_JITTER", 0),
+			ReconnectNotice:             os.Getenv("MAX_CONNECTION_RECONNECT_NOTICE"),
+			ReconnectGracePeriod:        getEnvDuration("MAX_CONNECTION_GRACE_PERIOD", 5*time.Second),
+			MaxConnectionCloseCode:      getEnvInt("MAX_CONNECTION_CLOSE_CODE", 4000),
+
+			MaxMessageSize: int64(getEnvInt("WS_MAX_MESSAGE_SIZE", 0)),
+			RateLimit:      getEnvFloat("WS_RATE_LIMIT", 0),
+			RateBurst:      getEnvInt("WS_RATE_BURST", 0),
+			LimitAction:    getEnvOrDefault("WS_LIMIT_ACTION", "drop"),
+
+			MaxConnections:            getEnvInt("MAX_CONNECTIONS", 0),
+			MaxConnectionsPerIP:       getEnvInt("MAX_CONNECTIONS_PER_IP", 0),
+			ConnectionLimitRetryAfter: getEnvDuration("CONNECTION_LIMIT_RETRY_AFTER", 5*time.Second),
+
+			HandshakeRateLimit:      getEnvFloat("HANDSHAKE_RATE_LIMIT", 0),
+			HandshakeBurst:          getEnvInt("HANDSHAKE_BURST", 0),
+			HandshakeRateLimitPerIP: getEnvFloat("HANDSHAKE_RATE_LIMIT_PER_IP", 0),
+			HandshakeBurstPerIP:     getEnvInt("HANDSHAKE_BURST_PER_IP", 0),
+			HandshakeRetryAfter:     getEnvDuration("HANDSHAKE_RETRY_AFTER", time.Second),
+			HandshakeRetryJitter:    getEnvDuration("HANDSHAKE_RETRY_JITTER", 10*time.Second),
+		},
+		JWT: JWTConfig{
+			Secret:       os.Getenv("JWT_SECRET"),
+			JWKSFile:     os.Getenv("JWT_JWKS_FILE"),
+			TokenSources: getEnvListOrDefault("JWT_TOKEN_SOURCES", []string{"header:Authorization", "query:token"}),
+			Issuer:       os.Getenv("JWT_ISSUER"),
+			Audience:     os.Getenv("JWT_AUDIENCE"),
+			Leeway:       getEnvDuration("JWT_LEEWAY", 30*time.Second),
+		},
+		Admin: AdminConfig{
+			APIKeys:     adminAPIKeys,
+			HMACMaxSkew: getEnvDuration("ADMIN_HMAC_MAX_SKEW", 5*time.Minute),
+			CertScopes:  getEnvMap("ADMIN_CERT_SCOPES"),
+			TLS: TLSConfig{
+				CertFile:       os.Getenv("ADMIN_TLS_CERT_FILE"),
+				KeyFile:        os.Getenv("ADMIN_TLS_KEY_FILE"),
+				MinVersion:     getEnvOrDefault("ADMIN_TLS_MIN_VERSION", "1.2"),
+				CipherSuites:   getEnvList("ADMIN_TLS_CIPHER_SUITES"),
+				ReloadInterval: getEnvDuration("TLS_RELOAD_INTERVAL", 30*time.Second),
+				ClientCAFile:   os.Getenv("ADMIN_CLIENT_CA_FILE"),
+
+				RequireClientCert: len(adminAPIKeys) == 0,
+			},
 		},
 	}
 }
 
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+func (c *JWTConfig) Enabled() bool {
+	return c.Secret != "" || c.JWKSFile != ""
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
-} 
\ No newline at end of file
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvList(key string) []string {
+	var result []string
+
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+func getEnvListOrDefault(key string, defaultValue []string) []string {
+	if value := getEnvList(key); len(value) > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvMap(key string) map[string]string {
+	result := make(map[string]string)
+
+	value := os.Getenv(key)
+	if value == "" {
+		return result
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		name, target, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || name == "" {
+			continue
+		}
+		result[strings.TrimSpace(name)] = strings.TrimSpace(target)
+	}
+
+	return result
+}
\ No newline at end of file
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gomw-gw/app/internal/models"
+	"gomw-gw/app/internal/services"
+	"gomw-gw/app/pkg/logger"
+)
+
+const (
+	membershipStatusSubscribed    = "subscribed"
+	membershipStatusUnsubscribed  = "unsubscribed"
+	membershipStatusNotSubscribed = "not_subscribed"
+	membershipStatusNotFound      = "not_found"
+	membershipStatusLimitReached  = "subscription_limit"
+)
+
+type ChannelHandler struct {
+	channelManager *services.ChannelManager
+}
+
+func NewChannelHandler(channelManager *services.ChannelManager) *ChannelHandler {
+	return &ChannelHandler{
+		channelManager: channelManager,
+	}
+}
+
+func (h *ChannelHandler) HandleGetChannel(w http.ResponseWriter, r *http.Request) {
+	channel := r.PathValue("name")
+	members := h.channelManager.Members(channel)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"channel":        channel,
+		"total_members":  len(members),
+		"connection_ids": members,
+	}); err != nil {
+		logger.Error("Failed to encode channel info", logger.Fields{
+			"channel": channel,
+			"error":   err.Error(),
+		})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *ChannelHandler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
+	channel := r.PathValue("name")
+
+	request, ok := decodeMembershipRequest(w, r)
+	if !ok {
+		return
+	}
+
+	results := make(map[models.ConnectionID]string, len(request.ConnectionIDs))
+	for _, connectionID := range request.ConnectionIDs {
+		if _, err := h.channelManager.Subscribe(channel, connectionID); err != nil {
+			results[connectionID] = membershipStatusNotFound
+			if errors.Is(err, services.ErrSubscriptionLimitReached) {
+				results[connectionID] = membershipStatusLimitReached
+			}
+			continue
+		}
+		results[connectionID] = membershipStatusSubscribed
+	}
+
+	logger.Info("Connections subscribed to channel", logger.Fields{
+		"channel":     channel,
+		"requested":   len(request.ConnectionIDs),
+		"remote_addr": r.RemoteAddr,
+	})
+
+	writeMembershipResponse(w, channel, results)
+}
+
+func (h *ChannelHandler) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	channel := r.PathValue("name")
+
+	request, ok := decodeMembershipRequest(w, r)
+	if !ok {
+		return
+	}
+
+	results := make(map[models.ConnectionID]string, len(request.ConnectionIDs))
+	for _, connectionID := range request.ConnectionIDs {
+		if h.channelManager.Unsubscribe(channel, connectionID) {
+			results[connectionID] = membershipStatusUnsubscribed
+		} else {
+			results[connectionID] = membershipStatusNotSubscribed
+		}
+	}
+
+	logger.Info("Connections unsubscribed from channel", logger.Fields{
+		"channel":     channel,
+		"requested":   len(request.ConnectionIDs),
+		"remote_addr": r.RemoteAddr,
+	})
+
+	writeMembershipResponse(w, channel, results)
+}
+
+func (h *ChannelHandler) HandleChannelSend(w http.ResponseWriter, r *http.Request) {
+	channel := r.PathValue("name")
+
+	var request models.ChannelMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Warn("Invalid JSON in channel send request", logger.Fields{
+			"error":       err.Error(),
+			"remote_addr": r.RemoteAddr,
+		})
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Message) == 0 {
+		http.Error(w, "message is required", http.StatusBadRequest)
+		return
+	}
+
+	sessions := h.channelManager.MemberSessions(channel)
+	sent, failed := fanOut(sessions, request.Message)
+
+	logger.Info("Channel message sent", logger.Fields{
+		"channel":      channel,
+		"sent":         sent,
+		"failed":       failed,
+		"message_size": len(request.Message),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"channel": channel,
+		"matched": len(sessions),
+		"sent":    sent,
+		"failed":  failed,
+	})
+}
+
+func decodeMembershipRequest(w http.ResponseWriter, r *http.Request) (*models.ChannelMembershipRequest, bool) {
+	var request models.ChannelMembershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Warn("Invalid JSON in channel membership request", logger.Fields{
+			"error":       err.Error(),
+			"remote_addr": r.RemoteAddr,
+		})
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if len(request.ConnectionIDs) == 0 {
+		http.Error(w, "connection_ids is required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &request, true
+}
+
+func writeMembershipResponse(w http.ResponseWriter, channel string, results map[models.ConnectionID]string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"channel": channel,
+		"results": results,
+	})
+}
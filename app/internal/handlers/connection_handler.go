@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"gomw-gw/app/internal/models"
+	"gomw-gw/app/internal/services"
+	"gomw-gw/app/pkg/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+const maxCloseReasonLength = 123
+
+type ConnectionHandler struct {
+	sessionManager *services.SessionManager
+	channelManager *services.ChannelManager
+}
+
+func NewConnectionHandler(sessionManager *services.SessionManager, channelManager *services.ChannelManager) *ConnectionHandler {
+	return &ConnectionHandler{
+		sessionManager: sessionManager,
+		channelManager: channelManager,
+	}
+}
+
+func (h *ConnectionHandler) HandleGetConnection(w http.ResponseWriter, r *http.Request) {
+	connectionID := models.ConnectionID(r.PathValue("id"))
+
+	session, exists := h.sessionManager.GetSession(connectionID)
+	if !exists {
+		http.Error(w, "Connection not found", http.StatusNotFound)
+		return
+	}
+
+	info := sessionInfo(session)
+	info["channels"] = h.channelManager.Channels(connectionID)
+	if userID := h.sessionManager.GetUserID(connectionID); userID != "" {
+		info["user_id"] = userID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		logger.Error("Failed to encode connection info", logger.Fields{
+			"connection_id": string(connectionID),
+			"error":         err.Error(),
+		})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Debug("Connection info requested", logger.Fields{
+		"connection_id": string(connectionID),
+		"remote_addr":   r.RemoteAddr,
+	})
+}
+
+func (h *ConnectionHandler) HandleDeleteConnection(w http.ResponseWriter, r *http.Request) {
+	connectionID := models.ConnectionID(r.PathValue("id"))
+
+	request, ok := decodeCloseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	session, exists := h.sessionManager.GetSession(connectionID)
+	if !exists {
+		http.Error(w, "Connection not found", http.StatusNotFound)
+		return
+	}
+
+	if err := session.CloseWithCode(request.Code, request.Reason, models.DisconnectReasonAdminAPI); err != nil {
+		logger.Warn("Failed to close WebSocket connection", logger.Fields{
+			"connection_id": string(connectionID),
+			"error":         err.Error(),
+		})
+	}
+
+	logger.Info("Connection closed via admin API", logger.Fields{
+		"connection_id": string(connectionID),
+		"close_code":    request.Code,
+		"close_reason":  request.Reason,
+		"remote_addr":   r.RemoteAddr,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"connection_id": connectionID,
+		"message":       "Connection closed successfully",
+	})
+}
+
+func decodeCloseRequest(w http.ResponseWriter, r *http.Request) (*models.CloseConnectionRequest, bool) {
+	request := models.CloseConnectionRequest{
+		Code: websocket.CloseNormalClosure,
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		logger.Warn("Invalid JSON in close connection request", logger.Fields{
+			"error":       err.Error(),
+			"remote_addr": r.RemoteAddr,
+		})
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if !isValidCloseCode(request.Code) {
+		http.Error(w, "code must be 1000-1003, 1007-1011 or 3000-4999", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if len(request.Reason) > maxCloseReasonLength {
+		http.Error(w, "reason must not exceed 123 bytes", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &request, true
+}
+
+func isValidCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003:
+		return true
+	case code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
@@ -13,12 +13,14 @@ import (
 type InfoHandler struct {
 	config         *config.Config
 	sessionManager *services.SessionManager
+	metrics        *services.Metrics
 }
 
-func NewInfoHandler(cfg *config.Config, sessionManager *services.SessionManager) *InfoHandler {
+func NewInfoHandler(cfg *config.Config, sessionManager *services.SessionManager, metrics *services.Metrics) *InfoHandler {
 	return &InfoHandler{
 		config:         cfg,
 		sessionManager: sessionManager,
+		metrics:        metrics,
 	}
 }
 
@@ -32,6 +34,8 @@ func (h *InfoHandler) HandleEnvironmentInfo(w http.ResponseWriter, r *http.Reque
 		ListenAddress:   h.config.Server.ListenAddress,
 		OnConnectURL:    h.config.Webhook.OnConnectURL,
 		OnDisconnectURL: h.config.Webhook.OnDisconnectURL,
+		MessageURL:      h.config.Webhook.MessageURL,
+		Routes:          h.config.Webhook.Routes,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -59,6 +63,7 @@ func (h *InfoHandler) HandleHealthCheck(w http.ResponseWriter, r *http.Request)
 	healthInfo := map[string]interface{}{
 		"status":             "healthy",
 		"active_connections": activeConnections,
+		"counters":           h.metrics.Snapshot(),
 		"timestamp":          "2024-01-01T00:00:00Z",
 	}
 
@@ -82,13 +87,7 @@ func (h *InfoHandler) HandleConnectionStatus(w http.ResponseWriter, r *http.Requ
 	
 	connectionInfo := make([]map[string]interface{}, 0, len(sessions))
 	for _, session := range sessions {
-		info := map[string]interface{}{
-			"connection_id": session.ID,
-			"client_ip":     session.ClientIP,
-			"connected_at":  session.ConnectedAt,
-			"query_params":  session.QueryParams,
-		}
-		connectionInfo = append(connectionInfo, info)
+		connectionInfo = append(connectionInfo, sessionInfo(session))
 	}
 
 	statusInfo := map[string]interface{}{
@@ -109,4 +108,17 @@ func (h *InfoHandler) HandleConnectionStatus(w http.ResponseWriter, r *http.Requ
 		"remote_addr":        r.RemoteAddr,
 		"active_connections": len(sessions),
 	})
-} 
\ No newline at end of file
+}
+
+func sessionInfo(session *models.Session) map[string]interface{} {
+	return map[string]interface{}{
+		"connection_id": session.ID,
+		"client_ip":     session.ClientIP,
+		"connected_at":  session.ConnectedAt,
+		"subprotocol":   session.Subprotocol,
+		"query_params":  session.QueryParams,
+		"context":       session.GetContext(),
+		"queued":        session.QueueLength(),
+		"compression":   session.CompressionStats(),
+	}
+}
\ No newline at end of file
@@ -57,36 +57,191 @@ func (h *MessageHandler) HandleSendMessage(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if !session.IsValid() {
-		logger.Warn("Invalid session for send request", logger.Fields{
-			"connection_id": string(request.ConnectionID),
-		})
+	status := sendToSession(session, request.Message)
+	switch status {
+	case models.SendStatusGone:
 		http.Error(w, "Invalid connection", http.StatusGone)
 		return
-	}
-
-	if err := session.Connection.WriteMessage(websocket.TextMessage, request.Message); err != nil {
-		logger.Error("Failed to send message to WebSocket", logger.Fields{
-			"connection_id": string(request.ConnectionID),
-			"error":         err.Error(),
-		})
-		
-		h.sessionManager.RemoveSession(request.ConnectionID)
-		
-		http.Error(w, "Failed to send message", http.StatusBadGateway)
+	case models.SendStatusDropped:
+		http.Error(w, "Send queue full, message dropped", http.StatusServiceUnavailable)
+		return
+	case models.SendStatusDisconnected:
+		http.Error(w, "Send queue full, slow consumer disconnected", http.StatusServiceUnavailable)
 		return
 	}
 
 	logger.Info("Message sent successfully", logger.Fields{
 		"connection_id": string(request.ConnectionID),
 		"message_size":  len(request.Message),
+		"status":        status,
 	})
 
+	message := "Message sent successfully"
+	if status == models.SendStatusDroppedOldest {
+		message = "Message queued, oldest pending message dropped"
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success":       true,
 		"connection_id": request.ConnectionID,
-		"message":       "Message sent successfully",
+		"status":        status,
+		"message":       message,
+	})
+}
+
+func (h *MessageHandler) HandleBatchSendMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request models.BatchSendRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Warn("Invalid JSON in batch send request", logger.Fields{
+			"error":       err.Error(),
+			"remote_addr": r.RemoteAddr,
+		})
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	messages := request.Messages
+	switch {
+	case len(request.ConnectionIDs) > 0 && len(request.Messages) > 0:
+		http.Error(w, "connection_ids and messages are mutually exclusive", http.StatusBadRequest)
+		return
+	case len(request.ConnectionIDs) > 0:
+		if len(request.Message) == 0 {
+			http.Error(w, "message is required", http.StatusBadRequest)
+			return
+		}
+		messages = make([]models.SendMessageRequest, 0, len(request.ConnectionIDs))
+		for _, connectionID := range request.ConnectionIDs {
+			messages = append(messages, models.SendMessageRequest{
+				ConnectionID: connectionID,
+				Message:      request.Message,
+			})
+		}
+	case len(request.Messages) > 0:
+		for _, message := range request.Messages {
+			if message.ConnectionID == "" || len(message.Message) == 0 {
+				http.Error(w, "every message requires connection_id and message", http.StatusBadRequest)
+				return
+			}
+		}
+	default:
+		http.Error(w, "connection_ids or messages is required", http.StatusBadRequest)
+		return
+	}
+
+	results := make([]models.SendResult, 0, len(messages))
+	summary := make(map[string]int)
+	for _, message := range messages {
+		status := models.SendStatusNotFound
+		if session, exists := h.sessionManager.GetSession(message.ConnectionID); exists {
+			status = sendToSession(session, message.Message)
+		}
+
+		results = append(results, models.SendResult{
+			ConnectionID: message.ConnectionID,
+			Status:       status,
+		})
+		summary[status]++
+	}
+
+	logger.Info("Batch messages sent", logger.Fields{
+		"requested": len(messages),
+		"summary":   summary,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"summary": summary,
+		"results": results,
+	})
+}
+
+func (h *MessageHandler) HandleBroadcast(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request models.BroadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Warn("Invalid JSON in broadcast request", logger.Fields{
+			"error":       err.Error(),
+			"remote_addr": r.RemoteAddr,
+		})
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Message) == 0 {
+		http.Error(w, "message is required", http.StatusBadRequest)
+		return
+	}
+
+	var sessions []*models.Session
+	for _, session := range h.sessionManager.GetAllSessions() {
+		if request.Selector.Matches(session) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	matched := len(sessions)
+	sent, failed := fanOut(sessions, request.Message)
+
+	logger.Info("Broadcast sent", logger.Fields{
+		"matched":      matched,
+		"sent":         sent,
+		"failed":       failed,
+		"message_size": len(request.Message),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"matched": matched,
+		"sent":    sent,
+		"failed":  failed,
 	})
-} 
\ No newline at end of file
+}
+
+func fanOut(sessions []*models.Session, message []byte) (int, int) {
+	sent, failed := 0, 0
+	for _, session := range sessions {
+		switch sendToSession(session, message) {
+		case models.SendStatusSent, models.SendStatusDroppedOldest:
+			sent++
+		default:
+			failed++
+		}
+	}
+	return sent, failed
+}
+
+func sendToSession(session *models.Session, message []byte) string {
+	if !session.IsValid() {
+		logger.Warn("Invalid session for send request", logger.Fields{
+			"connection_id": string(session.ID),
+		})
+		return models.SendStatusGone
+	}
+
+	status := session.Enqueue(websocket.TextMessage, message)
+	switch status {
+	case models.SendStatusDropped, models.SendStatusDroppedOldest, models.SendStatusDisconnected:
+		logger.Warn("Send queue overflow", logger.Fields{
+			"connection_id": string(session.ID),
+			"status":        status,
+		})
+	}
+
+	return status
+}
\ No newline at end of file
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"gomw-gw/app/internal/models"
+	"gomw-gw/app/internal/services"
+	"gomw-gw/app/pkg/logger"
+)
+
+type UserHandler struct {
+	sessionManager *services.SessionManager
+}
+
+func NewUserHandler(sessionManager *services.SessionManager) *UserHandler {
+	return &UserHandler{
+		sessionManager: sessionManager,
+	}
+}
+
+func (h *UserHandler) HandleUserSend(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	var request models.UserMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Warn("Invalid JSON in user send request", logger.Fields{
+			"error":       err.Error(),
+			"remote_addr": r.RemoteAddr,
+		})
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Message) == 0 {
+		http.Error(w, "message is required", http.StatusBadRequest)
+		return
+	}
+
+	sessions := h.sessionManager.GetUserSessions(userID)
+	if len(sessions) == 0 {
+		http.Error(w, "User has no connections", http.StatusNotFound)
+		return
+	}
+
+	sent, failed := fanOut(sessions, request.Message)
+
+	logger.Info("User message sent", logger.Fields{
+		"user_id":      userID,
+		"sent":         sent,
+		"failed":       failed,
+		"message_size": len(request.Message),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"user_id": userID,
+		"matched": len(sessions),
+		"sent":    sent,
+		"failed":  failed,
+	})
+}
+
+func (h *UserHandler) HandleDeleteUserConnections(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	request, ok := decodeCloseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	sessions := h.sessionManager.GetUserSessions(userID)
+	connectionIDs := make([]models.ConnectionID, 0, len(sessions))
+	for _, session := range sessions {
+		if err := session.CloseWithCode(request.Code, request.Reason, models.DisconnectReasonAdminAPI); err != nil {
+			logger.Warn("Failed to close WebSocket connection", logger.Fields{
+				"connection_id": string(session.ID),
+				"error":         err.Error(),
+			})
+		}
+		connectionIDs = append(connectionIDs, session.ID)
+	}
+
+	logger.Info("User connections closed via admin API", logger.Fields{
+		"user_id":     userID,
+		"closed":      len(connectionIDs),
+		"close_code":  request.Code,
+		"remote_addr": r.RemoteAddr,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":        true,
+		"user_id":        userID,
+		"closed":         len(connectionIDs),
+		"connection_ids": connectionIDs,
+	})
+}
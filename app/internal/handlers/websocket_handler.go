@@ -1,70 +1,300 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
 	"net"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"gomw-gw/app/internal/config"
 	"gomw-gw/app/internal/models"
 	"gomw-gw/app/internal/services"
 	"gomw-gw/app/pkg/logger"
+	"gomw-gw/app/pkg/network"
+	"gomw-gw/app/pkg/ratelimit"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
+const (
+	limitActionDrop  = "drop"
+	limitActionError = "error"
+	limitActionClose = "close"
+)
+
+const limitBreachLogInterval = 10 * time.Second
+
+var errMessageTooLarge = errors.New("message exceeds maximum size")
+
+type limitBreach struct {
+	metric    string
+	errorCode string
+	closeCode int
+	closeText string
+	reason    string
+}
+
+var (
+	messageSizeBreach = limitBreach{
+		metric:    services.MetricOversizedMessages,
+		errorCode: "message_too_large",
+		closeCode: websocket.CloseMessageTooBig,
+		closeText: "message too large",
+		reason:    models.DisconnectReasonMessageTooLarge,
+	}
+	rateLimitBreach = limitBreach{
+		metric:    services.MetricRateLimitedMessages,
+		errorCode: "rate_limited",
+		closeCode: websocket.ClosePolicyViolation,
+		closeText: "rate limit exceeded",
+		reason:    models.DisconnectReasonRateLimited,
+	}
+	messageQueueBreach = limitBreach{
+		metric:    services.MetricMessageQueueOverflow,
+		errorCode: "message_queue_full",
+		closeCode: websocket.CloseTryAgainLater,
+		closeText: "message queue full",
+		reason:    models.DisconnectReasonMessageQueueFull,
+	}
+)
+
+type breachLog struct {
+	lastLogged time.Time
+	suppressed map[string]int
+}
+
+func (l *breachLog) shouldLog(limit string, now time.Time) bool {
+	if !l.lastLogged.IsZero() && now.Sub(l.lastLogged) < limitBreachLogInterval {
+		if l.suppressed == nil {
+			l.suppressed = make(map[string]int)
+		}
+		l.suppressed[limit]++
+		return false
+	}
+
+	l.lastLogged = now
+	return true
+}
+
+type countingResponseWriter struct {
+	http.ResponseWriter
+	conn *network.CountingConn
+}
+
+func (w *countingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w.conn = network.NewCountingConn(conn)
+	return w.conn, rw, nil
+}
+
 type WebSocketHandler struct {
 	upgrader       websocket.Upgrader
+	config         *config.WebSocketConfig
 	sessionManager *services.SessionManager
+	channelManager *services.ChannelManager
 	webhookService *services.WebhookService
+	jwtAuthorizer  *services.JWTAuthorizer
+	metrics        *services.Metrics
+	originChecker  *services.OriginChecker
+	ipResolver     *services.ClientIPResolver
+
+	compressionLevel int
+
+	handshakeLimiter      *ratelimit.TokenBucket
+	handshakeLimiterPerIP *ratelimit.KeyedLimiter
 }
 
 func NewWebSocketHandler(
 	cfg *config.WebSocketConfig,
 	sessionManager *services.SessionManager,
+	channelManager *services.ChannelManager,
 	webhookService *services.WebhookService,
+	jwtAuthorizer *services.JWTAuthorizer,
+	metrics *services.Metrics,
 ) *WebSocketHandler {
-	return &WebSocketHandler{
+	originChecker := services.NewOriginChecker(cfg)
+
+	h := &WebSocketHandler{
 		upgrader: websocket.Upgrader{
-			ReadBufferSize:  cfg.ReadBufferSize,
-			WriteBufferSize: cfg.WriteBufferSize,
+			ReadBufferSize:    cfg.ReadBufferSize,
+			WriteBufferSize:   cfg.WriteBufferSize,
+			Subprotocols:      cfg.Subprotocols,
+			EnableCompression: cfg.CompressionEnabled,
 			CheckOrigin: func(r *http.Request) bool {
-				return cfg.CheckOrigin
+				return originChecker.Allowed(r.Header.Get("Origin"))
 			},
 		},
+		config:         cfg,
 		sessionManager: sessionManager,
+		channelManager: channelManager,
 		webhookService: webhookService,
+		jwtAuthorizer:  jwtAuthorizer,
+		metrics:        metrics,
+		originChecker:  originChecker,
+		ipResolver:     services.NewClientIPResolver(cfg),
+
+		compressionLevel: cfg.CompressionLevel,
 	}
-}
 
-func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
-	conn, err := h.upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		logger.Warn("WebSocket upgrade failed", logger.Fields{
-			"error":      err.Error(),
-			"remote_addr": r.RemoteAddr,
+	if cfg.CompressionLevel < flate.HuffmanOnly || cfg.CompressionLevel > flate.BestCompression {
+		logger.Warn("Ignoring invalid compression level", logger.Fields{
+			"compression_level": cfg.CompressionLevel,
 		})
-		return
+		h.compressionLevel = flate.BestSpeed
+	}
+
+	if cfg.HandshakeRateLimit > 0 {
+		h.handshakeLimiter = ratelimit.NewTokenBucket(cfg.HandshakeRateLimit, cfg.HandshakeBurst)
+	}
+	if cfg.HandshakeRateLimitPerIP > 0 {
+		h.handshakeLimiterPerIP = ratelimit.NewKeyedLimiter(cfg.HandshakeRateLimitPerIP, cfg.HandshakeBurstPerIP)
 	}
 
+	return h
+}
+
+func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	connectionID := models.ConnectionID(uuid.NewString())
-	clientIP := h.extractClientIP(r)
+	clientIP := h.ipResolver.Resolve(r)
 
 	session := &models.Session{
 		ID:          connectionID,
-		Connection:  conn,
 		ClientIP:    clientIP,
 		QueryParams: r.URL.Query(),
 		ConnectedAt: time.Now(),
 	}
 
+	if origin := r.Header.Get("Origin"); !h.originChecker.Allowed(origin) {
+		h.metrics.Increment(services.MetricRejectedOrigin)
+		logger.Warn("WebSocket handshake rejected by origin policy", logger.Fields{
+			"connection_id": string(connectionID),
+			"client_ip":     clientIP,
+			"origin":        origin,
+		})
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	if statusCode, allowed := h.allowHandshake(clientIP); !allowed {
+		h.metrics.Increment(services.MetricRejectedHandshakeRate)
+		logger.Warn("WebSocket handshake rejected by rate limiter", logger.Fields{
+			"connection_id": string(connectionID),
+			"client_ip":     clientIP,
+			"status_code":   statusCode,
+		})
+		rejectWithRetryAfter(w, statusCode, h.config.HandshakeRetryAfter, h.config.HandshakeRetryJitter)
+		return
+	}
+
+	if err := h.sessionManager.ReserveConnection(clientIP); err != nil {
+		statusCode := http.StatusServiceUnavailable
+		if errors.Is(err, services.ErrIPConnectionLimitReached) {
+			statusCode = http.StatusTooManyRequests
+		}
+
+		h.metrics.Increment(services.MetricRejectedConnectionLimit)
+		logger.Warn("WebSocket handshake rejected by connection limit", logger.Fields{
+			"connection_id": string(connectionID),
+			"client_ip":     clientIP,
+			"error":         err.Error(),
+		})
+		rejectWithRetryAfter(w, statusCode, h.config.ConnectionLimitRetryAfter, 0)
+		return
+	}
+
+	added := false
+	defer func() {
+		if !added {
+			h.sessionManager.ReleaseConnection(clientIP)
+		}
+	}()
+
+	responseHeader := http.Header{}
+
+	if h.jwtAuthorizer != nil {
+		jwtAuthorization, err := h.jwtAuthorizer.Authorize(r)
+		if err != nil {
+			logger.Warn("WebSocket handshake rejected by JWT authorizer", logger.Fields{
+				"connection_id": string(connectionID),
+				"client_ip":     clientIP,
+				"error":         err.Error(),
+			})
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		session.MergeContext(jwtAuthorization.Claims)
+		if jwtAuthorization.QueryParam != "" {
+			session.QueryParams.Del(jwtAuthorization.QueryParam)
+		}
+		if jwtAuthorization.Subprotocol != "" {
+			responseHeader.Set("Sec-WebSocket-Protocol", jwtAuthorization.Subprotocol)
+		}
+	}
+
+	session.Subprotocol = h.selectSubprotocol(r, responseHeader)
+
+	authorization := h.webhookService.AuthorizeConnection(session, r.Header)
+	if !authorization.Allowed {
+		logger.Warn("WebSocket handshake rejected by authorizer", logger.Fields{
+			"connection_id": string(connectionID),
+			"client_ip":     clientIP,
+			"status_code":   authorization.StatusCode,
+		})
+		if authorization.Throttled {
+			h.metrics.Increment(services.MetricRejectedHandshakeRate)
+			rejectWithRetryAfter(w, authorization.StatusCode, h.config.HandshakeRetryAfter, h.config.HandshakeRetryJitter)
+			return
+		}
+		http.Error(w, http.StatusText(authorization.StatusCode), authorization.StatusCode)
+		return
+	}
+
+	countingWriter := &countingResponseWriter{ResponseWriter: w}
+	conn, err := h.upgrader.Upgrade(countingWriter, r, responseHeader)
+	if err != nil {
+		logger.Warn("WebSocket upgrade failed", logger.Fields{
+			"error":      err.Error(),
+			"remote_addr": r.RemoteAddr,
+		})
+		return
+	}
+
+	if h.config.CompressionEnabled {
+		conn.SetCompressionLevel(h.compressionLevel)
+	}
+
+	session.Connection = conn
+	session.StartWritePump(models.WritePumpOptions{
+		QueueSize:          h.config.SendQueueSize,
+		WriteTimeout:       h.config.WriteTimeout,
+		OverflowPolicy:     h.config.OverflowPolicy,
+		PingInterval:       h.config.PingInterval,
+		CompressionMinSize: h.config.CompressionMinSize,
+		WireConn:           countingWriter.conn,
+	})
+
 	h.sessionManager.AddSession(session)
+	added = true
 
 	logger.Info("Client connected", logger.Fields{
 		"connection_id": string(connectionID),
 		"client_ip":     clientIP,
-		"query_params":  r.URL.Query(),
+		"subprotocol":   session.Subprotocol,
+		"query_params":  session.QueryParams,
 	})
 
 	h.webhookService.NotifyConnection(session)
@@ -76,16 +306,64 @@ func (h *WebSocketHandler) handleConnectionLoop(session *models.Session) {
 	defer func() {
 		h.sessionManager.RemoveSession(session.ID)
 		h.webhookService.NotifyDisconnection(session)
-		
+
+		reason, _ := session.DisconnectReason()
 		logger.Info("Client disconnected", logger.Fields{
 			"connection_id": string(session.ID),
 			"client_ip":     session.ClientIP,
+			"reason":        reason,
 		})
 	}()
 
+	keepalive := h.config.PingInterval > 0 && h.config.PongWait > 0
+	if keepalive {
+		session.Connection.SetReadDeadline(time.Now().Add(h.config.PongWait))
+		session.Connection.SetPongHandler(func(string) error {
+			return session.Connection.SetReadDeadline(time.Now().Add(h.config.PongWait))
+		})
+	}
+
+	if h.config.MaxConnectionDuration > 0 {
+		stopExpiry := h.scheduleExpiry(session)
+		defer stopExpiry()
+	}
+
+	var idleTimer *time.Timer
+	if h.config.IdleTimeout > 0 {
+		idleTimer = time.AfterFunc(h.config.IdleTimeout, func() {
+			session.CloseWithCode(websocket.CloseNormalClosure, "idle timeout", models.DisconnectReasonIdleTimeout)
+		})
+		defer idleTimer.Stop()
+	}
+
+	if h.config.MaxMessageSize > 0 && h.config.LimitAction == limitActionClose {
+		session.Connection.SetReadLimit(h.config.MaxMessageSize)
+	}
+
+	var rateLimiter *ratelimit.TokenBucket
+	if h.config.RateLimit > 0 {
+		rateLimiter = ratelimit.NewTokenBucket(h.config.RateLimit, h.config.RateBurst)
+	}
+
+	breaches := &breachLog{}
+	defer h.flushLimitBreaches(session, breaches)
+
 	for {
-		_, _, err := session.Connection.ReadMessage()
+		messageType, data, err := h.readMessage(session)
+		if errors.Is(err, errMessageTooLarge) {
+			h.handleLimitBreach(session, messageSizeBreach, breaches)
+			continue
+		}
 		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				session.SetDisconnectReason(models.DisconnectReasonPingTimeout, 0)
+			}
+			if errors.Is(err, websocket.ErrReadLimit) {
+				h.recordLimitBreach(session, messageSizeBreach, breaches)
+				session.SetDisconnectReason(messageSizeBreach.reason, messageSizeBreach.closeCode)
+			}
+
 			if websocket.IsUnexpectedCloseError(err,
 				websocket.CloseGoingAway,
 				websocket.CloseAbnormalClosure,
@@ -102,18 +380,243 @@ func (h *WebSocketHandler) handleConnectionLoop(session *models.Session) {
 			}
 			break
 		}
+
+		if keepalive {
+			session.Connection.SetReadDeadline(time.Now().Add(h.config.PongWait))
+		}
+		if idleTimer != nil {
+			idleTimer.Reset(h.config.IdleTimeout)
+		}
+
+		if rateLimiter != nil && !rateLimiter.Allow() {
+			h.handleLimitBreach(session, rateLimitBreach, breaches)
+			continue
+		}
+
+		if h.config.ControlFramesEnabled && messageType == websocket.TextMessage && h.handleControlFrame(session, data) {
+			continue
+		}
+
+		if !h.webhookService.NotifyMessage(session, messageType, data) {
+			h.handleLimitBreach(session, messageQueueBreach, breaches)
+		}
 	}
 }
 
-func (h *WebSocketHandler) extractClientIP(r *http.Request) string {
-	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-		return forwarded
+func (h *WebSocketHandler) readMessage(session *models.Session) (int, []byte, error) {
+	messageType, reader, err := session.Connection.NextReader()
+	if err != nil {
+		return messageType, nil, err
+	}
+
+	if h.config.MaxMessageSize <= 0 {
+		data, err := io.ReadAll(reader)
+		return messageType, data, err
 	}
-	
-	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
-		return realIP
+
+	data, err := io.ReadAll(io.LimitReader(reader, h.config.MaxMessageSize+1))
+	if err != nil {
+		return messageType, nil, err
+	}
+
+	if int64(len(data)) > h.config.MaxMessageSize {
+		if _, err := io.Copy(io.Discard, reader); err != nil {
+			return messageType, nil, err
+		}
+		return messageType, nil, errMessageTooLarge
 	}
-	
-	clientIP, _, _ := net.SplitHostPort(r.RemoteAddr)
-	return clientIP
-} 
\ No newline at end of file
+
+	return messageType, data, nil
+}
+
+func (h *WebSocketHandler) handleLimitBreach(session *models.Session, breach limitBreach, breaches *breachLog) {
+	h.recordLimitBreach(session, breach, breaches)
+
+	switch h.config.LimitAction {
+	case limitActionError:
+		h.replyControlFrame(session, &models.ControlFrameReply{Error: breach.errorCode})
+	case limitActionClose:
+		session.CloseWithCode(breach.closeCode, breach.closeText, breach.reason)
+	}
+}
+
+func (h *WebSocketHandler) recordLimitBreach(session *models.Session, breach limitBreach, breaches *breachLog) {
+	h.metrics.Increment(breach.metric)
+
+	if !breaches.shouldLog(breach.errorCode, time.Now()) {
+		return
+	}
+
+	fields := logger.Fields{
+		"connection_id": string(session.ID),
+		"client_ip":     session.ClientIP,
+		"limit":         breach.errorCode,
+		"action":        h.config.LimitAction,
+	}
+	if len(breaches.suppressed) > 0 {
+		fields["suppressed"] = breaches.suppressed
+		breaches.suppressed = nil
+	}
+	logger.Warn("Inbound message limit exceeded", fields)
+}
+
+func (h *WebSocketHandler) flushLimitBreaches(session *models.Session, breaches *breachLog) {
+	if len(breaches.suppressed) == 0 {
+		return
+	}
+
+	logger.Warn("Inbound message limit exceeded", logger.Fields{
+		"connection_id": string(session.ID),
+		"client_ip":     session.ClientIP,
+		"action":        h.config.LimitAction,
+		"suppressed":    breaches.suppressed,
+	})
+}
+
+func (h *WebSocketHandler) scheduleExpiry(session *models.Session) func() {
+	var mu sync.Mutex
+	var graceTimer *time.Timer
+
+	closeSession := func() {
+		session.CloseWithCode(h.config.MaxConnectionCloseCode, "max connection duration reached", models.DisconnectReasonMaxDuration)
+	}
+
+	expiry := time.Until(session.ConnectedAt.Add(h.config.MaxConnectionDuration))
+	if h.config.MaxConnectionDurationJitter > 0 {
+		expiry += rand.N(h.config.MaxConnectionDurationJitter)
+	}
+	expiryTimer := time.AfterFunc(expiry, func() {
+		if h.config.ReconnectNotice == "" || h.config.ReconnectGracePeriod <= 0 {
+			closeSession()
+			return
+		}
+
+		logger.Debug("Sending reconnect notice", logger.Fields{
+			"connection_id": string(session.ID),
+			"grace_period":  h.config.ReconnectGracePeriod.String(),
+		})
+		session.Enqueue(websocket.TextMessage, []byte(h.config.ReconnectNotice))
+
+		mu.Lock()
+		graceTimer = time.AfterFunc(h.config.ReconnectGracePeriod, closeSession)
+		mu.Unlock()
+	})
+
+	return func() {
+		expiryTimer.Stop()
+
+		mu.Lock()
+		if graceTimer != nil {
+			graceTimer.Stop()
+		}
+		mu.Unlock()
+	}
+}
+
+func (h *WebSocketHandler) handleControlFrame(session *models.Session, data []byte) bool {
+	if !bytes.Contains(data, []byte(`"$`)) {
+		return false
+	}
+
+	var frame models.ControlFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return false
+	}
+
+	switch {
+	case frame.Subscribe != "":
+		channel := frame.Subscribe
+		if !h.webhookService.AuthorizeSubscription(session, channel) {
+			logger.Info("Client subscription denied", logger.Fields{
+				"connection_id": string(session.ID),
+				"channel":       channel,
+			})
+			h.replyControlFrame(session, &models.ControlFrameReply{Error: "subscription_denied", Channel: channel})
+			return true
+		}
+
+		if _, err := h.channelManager.Subscribe(channel, session.ID); err != nil {
+			errorCode := "subscription_failed"
+			if errors.Is(err, services.ErrSubscriptionLimitReached) {
+				errorCode = "subscription_limit"
+			}
+			h.replyControlFrame(session, &models.ControlFrameReply{Error: errorCode, Channel: channel})
+			return true
+		}
+
+		logger.Debug("Client subscribed to channel", logger.Fields{
+			"connection_id": string(session.ID),
+			"channel":       channel,
+		})
+		h.replyControlFrame(session, &models.ControlFrameReply{Subscribed: channel})
+		return true
+
+	case frame.Unsubscribe != "":
+		channel := frame.Unsubscribe
+		h.channelManager.Unsubscribe(channel, session.ID)
+
+		logger.Debug("Client unsubscribed from channel", logger.Fields{
+			"connection_id": string(session.ID),
+			"channel":       channel,
+		})
+		h.replyControlFrame(session, &models.ControlFrameReply{Unsubscribed: channel})
+		return true
+	}
+
+	return false
+}
+
+func (h *WebSocketHandler) replyControlFrame(session *models.Session, reply *models.ControlFrameReply) {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return
+	}
+
+	if status := session.Enqueue(websocket.TextMessage, data); status != models.SendStatusSent && status != models.SendStatusDroppedOldest {
+		logger.Warn("Failed to send control frame reply", logger.Fields{
+			"connection_id": string(session.ID),
+			"status":        status,
+		})
+	}
+}
+
+func (h *WebSocketHandler) selectSubprotocol(r *http.Request, responseHeader http.Header) string {
+	if len(h.upgrader.Subprotocols) == 0 {
+		return responseHeader.Get("Sec-WebSocket-Protocol")
+	}
+
+	offered := websocket.Subprotocols(r)
+	for _, supported := range h.upgrader.Subprotocols {
+		for _, protocol := range offered {
+			if protocol == supported {
+				return protocol
+			}
+		}
+	}
+	return ""
+}
+
+func (h *WebSocketHandler) allowHandshake(clientIP string) (int, bool) {
+	if h.handshakeLimiterPerIP != nil && !h.handshakeLimiterPerIP.Allow(clientIP) {
+		return http.StatusTooManyRequests, false
+	}
+	if h.handshakeLimiter != nil && !h.handshakeLimiter.Allow() {
+		return http.StatusServiceUnavailable, false
+	}
+	return 0, true
+}
+
+func rejectWithRetryAfter(w http.ResponseWriter, statusCode int, retryAfter, jitter time.Duration) {
+	if jitter > 0 {
+		retryAfter += rand.N(jitter)
+	}
+
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, http.StatusText(statusCode), statusCode)
+}
+ 
\ No newline at end of file
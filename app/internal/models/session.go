@@ -2,7 +2,10 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -10,12 +13,50 @@ import (
 
 type ConnectionID string
 
+const (
+	DisconnectReasonClientClosed     = "client_closed"
+	DisconnectReasonAdminAPI         = "admin_api"
+	DisconnectReasonSlowConsumer     = "slow_consumer"
+	DisconnectReasonWriteFailed      = "write_failed"
+	DisconnectReasonIdleTimeout      = "idle_timeout"
+	DisconnectReasonPingTimeout      = "ping_timeout"
+	DisconnectReasonMaxDuration      = "max_duration"
+	DisconnectReasonMessageTooLarge  = "message_too_large"
+	DisconnectReasonRateLimited      = "rate_limited"
+	DisconnectReasonMessageQueueFull = "message_queue_full"
+)
+
+const (
+	SendStatusSent          = "sent"
+	SendStatusDroppedOldest = "dropped_oldest"
+	SendStatusDropped       = "dropped"
+	SendStatusDisconnected  = "disconnected"
+	SendStatusNotFound      = "not_found"
+	SendStatusGone          = "gone"
+)
+
+const closeWriteTimeout = time.Second
+
 type Session struct {
 	ID         ConnectionID    `json:"id"`
 	Connection *websocket.Conn `json:"-"`
 	ClientIP   string          `json:"client_ip"`
 	QueryParams url.Values     `json:"query_params"`
 	ConnectedAt time.Time      `json:"connected_at"`
+	Subprotocol string         `json:"subprotocol"`
+
+	writer    *sessionWriter
+	contextMu sync.RWMutex
+	context   map[string]interface{}
+
+	disconnectMu     sync.Mutex
+	disconnectReason string
+	closeCode        int
+}
+
+type CloseConnectionRequest struct {
+	Code   int    `json:"code"`
+	Reason string `json:"reason"`
 }
 
 type SendMessageRequest struct {
@@ -23,26 +64,198 @@ type SendMessageRequest struct {
 	Message      json.RawMessage `json:"message"`
 }
 
-type WebhookPayload struct {
+type BatchSendRequest struct {
+	ConnectionIDs []ConnectionID       `json:"connection_ids,omitempty"`
+	Message       json.RawMessage      `json:"message,omitempty"`
+	Messages      []SendMessageRequest `json:"messages,omitempty"`
+}
+
+type SendResult struct {
 	ConnectionID ConnectionID `json:"connection_id"`
-	ClientIP     string       `json:"client_ip"`
-	QueryParams  url.Values   `json:"query_params,omitempty"`
-	Timestamp    time.Time    `json:"timestamp"`
-	ServerIP     string       `json:"server_ip"`
-	ServerPort   string       `json:"server_port"`
+	Status       string       `json:"status"`
+}
+
+type SessionSelector struct {
+	QueryParams map[string]string `json:"query_params,omitempty"`
+	ClientIP    string            `json:"client_ip,omitempty"`
+	Context     map[string]string `json:"context,omitempty"`
+}
+
+type BroadcastRequest struct {
+	Message  json.RawMessage  `json:"message"`
+	Selector *SessionSelector `json:"selector,omitempty"`
+}
+
+type ChannelMembershipRequest struct {
+	ConnectionIDs []ConnectionID `json:"connection_ids"`
+}
+
+type ChannelMessageRequest struct {
+	Message json.RawMessage `json:"message"`
+}
+
+type UserMessageRequest struct {
+	Message json.RawMessage `json:"message"`
+}
+
+type ControlFrame struct {
+	Subscribe   string `json:"$subscribe,omitempty"`
+	Unsubscribe string `json:"$unsubscribe,omitempty"`
+}
+
+type ControlFrameReply struct {
+	Subscribed   string `json:"$subscribed,omitempty"`
+	Unsubscribed string `json:"$unsubscribed,omitempty"`
+	Error        string `json:"$error,omitempty"`
+	Channel      string `json:"channel,omitempty"`
+}
+
+type SubscriptionPayload struct {
+	ConnectionID ConnectionID           `json:"connection_id"`
+	ClientIP     string                 `json:"client_ip"`
+	Subprotocol  string                 `json:"subprotocol,omitempty"`
+	Channel      string                 `json:"channel"`
+	Context      map[string]interface{} `json:"context,omitempty"`
+	Timestamp    time.Time              `json:"timestamp"`
+	ServerIP     string                 `json:"server_ip"`
+	ServerPort   string                 `json:"server_port"`
+}
+
+type WebhookPayload struct {
+	ConnectionID ConnectionID           `json:"connection_id"`
+	ClientIP     string                 `json:"client_ip"`
+	Subprotocol  string                 `json:"subprotocol,omitempty"`
+	QueryParams  url.Values             `json:"query_params,omitempty"`
+	Headers      http.Header            `json:"headers,omitempty"`
+	Context      map[string]interface{} `json:"context,omitempty"`
+	Reason       string                 `json:"reason,omitempty"`
+	CloseCode    int                    `json:"close_code,omitempty"`
+	Timestamp    time.Time              `json:"timestamp"`
+	ServerIP     string                 `json:"server_ip"`
+	ServerPort   string                 `json:"server_port"`
+}
+
+type MessagePayload struct {
+	ConnectionID    ConnectionID           `json:"connection_id"`
+	ClientIP        string                 `json:"client_ip"`
+	Subprotocol     string                 `json:"subprotocol,omitempty"`
+	RouteKey        string                 `json:"route_key"`
+	MessageType     string                 `json:"message_type"`
+	Body            string                 `json:"body"`
+	IsBase64Encoded bool                   `json:"is_base64_encoded"`
+	Context         map[string]interface{} `json:"context,omitempty"`
+	Timestamp       time.Time              `json:"timestamp"`
+	ServerIP        string                 `json:"server_ip"`
+	ServerPort      string                 `json:"server_port"`
 }
 
 type EnvironmentInfo struct {
-	ListenAddress   string `json:"listen_address"`
-	OnConnectURL    string `json:"on_connect_url"`
-	OnDisconnectURL string `json:"on_disconnect_url"`
+	ListenAddress   string            `json:"listen_address"`
+	OnConnectURL    string            `json:"on_connect_url"`
+	OnDisconnectURL string            `json:"on_disconnect_url"`
+	MessageURL      string            `json:"message_url"`
+	Routes          map[string]string `json:"routes,omitempty"`
+}
+
+func (sel *SessionSelector) Matches(session *Session) bool {
+	if sel == nil {
+		return true
+	}
+
+	if sel.ClientIP != "" && sel.ClientIP != session.ClientIP {
+		return false
+	}
+
+	for key, value := range sel.QueryParams {
+		if session.QueryParams.Get(key) != value {
+			return false
+		}
+	}
+
+	if len(sel.Context) > 0 {
+		context := session.GetContext()
+		for key, value := range sel.Context {
+			contextValue, exists := context[key]
+			if !exists || fmt.Sprint(contextValue) != value {
+				return false
+			}
+		}
+	}
+
+	return true
 }
 
 func (s *Session) IsValid() bool {
 	return s.Connection != nil && s.ID != ""
 }
 
+func (s *Session) MergeContext(values map[string]interface{}) {
+	if len(values) == 0 {
+		return
+	}
+
+	s.contextMu.Lock()
+	defer s.contextMu.Unlock()
+
+	if s.context == nil {
+		s.context = make(map[string]interface{}, len(values))
+	}
+	for key, value := range values {
+		s.context[key] = value
+	}
+}
+
+func (s *Session) GetContext() map[string]interface{} {
+	s.contextMu.RLock()
+	defer s.contextMu.RUnlock()
+
+	if s.context == nil {
+		return nil
+	}
+
+	context := make(map[string]interface{}, len(s.context))
+	for key, value := range s.context {
+		context[key] = value
+	}
+	return context
+}
+
+func (s *Session) SetDisconnectReason(reason string, closeCode int) {
+	s.disconnectMu.Lock()
+	defer s.disconnectMu.Unlock()
+
+	if s.disconnectReason == "" {
+		s.disconnectReason = reason
+		s.closeCode = closeCode
+	}
+}
+
+func (s *Session) DisconnectReason() (string, int) {
+	s.disconnectMu.Lock()
+	defer s.disconnectMu.Unlock()
+
+	if s.disconnectReason == "" {
+		return DisconnectReasonClientClosed, 0
+	}
+	return s.disconnectReason, s.closeCode
+}
+
+func (s *Session) CloseWithCode(closeCode int, text string, reason string) error {
+	if s.Connection == nil {
+		return nil
+	}
+
+	s.SetDisconnectReason(reason, closeCode)
+
+	message := websocket.FormatCloseMessage(closeCode, text)
+	s.Connection.WriteControl(websocket.CloseMessage, message, time.Now().Add(closeWriteTimeout))
+
+	return s.Connection.Close()
+}
+
 func (s *Session) Close() error {
+	s.stopWritePump()
+
 	if s.Connection != nil {
 		return s.Connection.Close()
 	}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gomw-gw/app/pkg/network"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	OverflowPolicyDropNewest = "drop_newest"
+	OverflowPolicyDropOldest = "drop_oldest"
+	OverflowPolicyDisconnect = "disconnect"
+)
+
+type WritePumpOptions struct {
+	QueueSize          int
+	WriteTimeout       time.Duration
+	OverflowPolicy     string
+	PingInterval       time.Duration
+	CompressionMinSize int
+	WireConn           *network.CountingConn
+}
+
+type CompressionStats struct {
+	MessageBytes int64   `json:"message_bytes"`
+	WireBytes    int64   `json:"wire_bytes"`
+	Ratio        float64 `json:"ratio"`
+}
+
+type outboundFrame struct {
+	messageType int
+	data        []byte
+}
+
+type sessionWriter struct {
+	queue          chan outboundFrame
+	done           chan struct{}
+	closeOnce      sync.Once
+	enqueueMu      sync.Mutex
+	closed         bool
+	writeTimeout   time.Duration
+	overflowPolicy string
+	pingInterval   time.Duration
+
+	compressionMinSize int
+	wireConn           *network.CountingConn
+	messageBytes       atomic.Int64
+	wireBytes          atomic.Int64
+}
+
+func (s *Session) StartWritePump(options WritePumpOptions) {
+	queueSize := options.QueueSize
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	s.writer = &sessionWriter{
+		queue:          make(chan outboundFrame, queueSize),
+		done:           make(chan struct{}),
+		writeTimeout:   options.WriteTimeout,
+		overflowPolicy: options.OverflowPolicy,
+		pingInterval:   options.PingInterval,
+
+		compressionMinSize: options.CompressionMinSize,
+		wireConn:           options.WireConn,
+	}
+
+	go s.writePump()
+}
+
+func (s *Session) Enqueue(messageType int, data []byte) string {
+	w := s.writer
+	if w == nil {
+		return SendStatusGone
+	}
+
+	frame := outboundFrame{messageType: messageType, data: data}
+
+	w.enqueueMu.Lock()
+	if w.closed {
+		w.enqueueMu.Unlock()
+		return SendStatusGone
+	}
+
+	select {
+	case w.queue <- frame:
+		w.enqueueMu.Unlock()
+		return SendStatusSent
+	default:
+	}
+
+	switch w.overflowPolicy {
+	case OverflowPolicyDropOldest:
+		defer w.enqueueMu.Unlock()
+
+		select {
+		case <-w.queue:
+		default:
+		}
+		select {
+		case w.queue <- frame:
+			return SendStatusDroppedOldest
+		default:
+			return SendStatusDropped
+		}
+
+	case OverflowPolicyDisconnect:
+		w.enqueueMu.Unlock()
+
+		s.CloseWithCode(websocket.CloseTryAgainLater, "slow consumer", DisconnectReasonSlowConsumer)
+		return SendStatusDisconnected
+
+	default:
+		w.enqueueMu.Unlock()
+		return SendStatusDropped
+	}
+}
+
+func (s *Session) QueueLength() int {
+	if s.writer == nil {
+		return 0
+	}
+	return len(s.writer.queue)
+}
+
+func (s *Session) CompressionStats() CompressionStats {
+	var stats CompressionStats
+	if s.writer == nil {
+		return stats
+	}
+
+	stats.MessageBytes = s.writer.messageBytes.Load()
+	stats.WireBytes = s.writer.wireBytes.Load()
+	if stats.MessageBytes > 0 {
+		stats.Ratio = float64(stats.WireBytes) / float64(stats.MessageBytes)
+	}
+	return stats
+}
+
+func (s *Session) writePump() {
+	w := s.writer
+
+	var pings <-chan time.Time
+	if w.pingInterval > 0 {
+		ticker := time.NewTicker(w.pingInterval)
+		defer ticker.Stop()
+		pings = ticker.C
+	}
+
+	for {
+		select {
+		case frame := <-w.queue:
+			if w.compressionMinSize > 0 {
+				s.Connection.EnableWriteCompression(len(frame.data) >= w.compressionMinSize)
+			}
+
+			var wireBefore int64
+			if w.wireConn != nil {
+				wireBefore = w.wireConn.BytesWritten()
+			}
+
+			s.Connection.SetWriteDeadline(w.writeDeadline())
+			if err := s.Connection.WriteMessage(frame.messageType, frame.data); err != nil {
+				s.SetDisconnectReason(DisconnectReasonWriteFailed, 0)
+				s.Close()
+				return
+			}
+			w.messageBytes.Add(int64(len(frame.data)))
+			if w.wireConn != nil {
+				w.wireBytes.Add(w.wireConn.BytesWritten() - wireBefore)
+			}
+		case <-pings:
+			if err := s.Connection.WriteControl(websocket.PingMessage, nil, w.writeDeadline()); err != nil {
+				s.SetDisconnectReason(DisconnectReasonPingTimeout, 0)
+				s.Close()
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *sessionWriter) writeDeadline() time.Time {
+	if w.writeTimeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(w.writeTimeout)
+}
+
+func (s *Session) stopWritePump() {
+	w := s.writer
+	if w == nil {
+		return
+	}
+
+	w.closeOnce.Do(func() {
+		w.enqueueMu.Lock()
+		w.closed = true
+		w.enqueueMu.Unlock()
+
+		close(w.done)
+	})
+}
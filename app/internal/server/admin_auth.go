@@ -0,0 +1,304 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gomw-gw/app/internal/config"
+	"gomw-gw/app/pkg/logger"
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeSend  = "send"
+	ScopeAdmin = "admin"
+
+	headerKeyID     = "X-Gomw-Key-Id"
+	headerTimestamp = "X-Gomw-Timestamp"
+	headerSignature = "X-Gomw-Signature"
+
+	maxSignedBodySize = 10 << 20
+)
+
+var (
+	errMissingCredentials  = errors.New("missing credentials")
+	errInvalidAPIKey       = errors.New("invalid API key")
+	errUnknownKeyID        = errors.New("unknown key id")
+	errInvalidTimestamp    = errors.New("invalid timestamp")
+	errStaleTimestamp      = errors.New("timestamp outside allowed skew")
+	errInvalidSignature    = errors.New("invalid signature")
+	errReplayedSignature   = errors.New("replayed signature")
+	errBodyTooLarge        = errors.New("request body too large")
+	errUnmappedCertificate = errors.New("client certificate has no scope mapping")
+)
+
+type adminCredential struct {
+	id     string
+	secret []byte
+	scopes map[string]bool
+}
+
+type AdminAuthenticator struct {
+	credentials     map[string]*adminCredential
+	certCredentials map[string]*adminCredential
+	maxSkew         time.Duration
+
+	seenMu         sync.Mutex
+	seenSignatures map[string]time.Time
+}
+
+func NewAdminAuthenticator(cfg *config.AdminConfig) (*AdminAuthenticator, error) {
+	if len(cfg.APIKeys) == 0 && len(cfg.CertScopes) == 0 {
+		return nil, nil
+	}
+
+	a := &AdminAuthenticator{
+		credentials:     make(map[string]*adminCredential, len(cfg.APIKeys)),
+		certCredentials: make(map[string]*adminCredential, len(cfg.CertScopes)),
+		maxSkew:         cfg.HMACMaxSkew,
+		seenSignatures:  make(map[string]time.Time),
+	}
+
+	for _, entry := range cfg.APIKeys {
+		credential, err := parseAdminCredential(entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := a.credentials[credential.id]; exists {
+			return nil, fmt.Errorf("duplicate admin API key id %q", credential.id)
+		}
+		a.credentials[credential.id] = credential
+	}
+
+	for identity, scopes := range cfg.CertScopes {
+		parsedScopes, err := parseScopes(identity, scopes)
+		if err != nil {
+			return nil, err
+		}
+		a.certCredentials[identity] = &adminCredential{
+			id:     "cert:" + identity,
+			scopes: parsedScopes,
+		}
+	}
+
+	return a, nil
+}
+
+func (a *AdminAuthenticator) Require(scope string, next http.HandlerFunc) http.HandlerFunc {
+	if a == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		credential, err := a.authenticate(r)
+		if err != nil {
+			a.audit(r, r.Header.Get(headerKeyID), scope, err.Error())
+
+			statusCode := http.StatusUnauthorized
+			if errors.Is(err, errBodyTooLarge) {
+				statusCode = http.StatusRequestEntityTooLarge
+			}
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(statusCode), statusCode)
+			return
+		}
+
+		if !credential.allows(scope) {
+			a.audit(r, credential.id, scope, "insufficient scope")
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		logger.Debug("Admin API request authenticated", logger.Fields{
+			"key_id": credential.id,
+			"method": r.Method,
+			"path":   r.URL.Path,
+		})
+
+		next(w, r)
+	}
+}
+
+func (a *AdminAuthenticator) authenticate(r *http.Request) (*adminCredential, error) {
+	certCredential, certErr := a.certificateCredential(r)
+	if certCredential != nil {
+		return certCredential, nil
+	}
+
+	if keyID := r.Header.Get(headerKeyID); keyID != "" {
+		return a.verifySignature(r, keyID)
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		if certErr != nil {
+			return nil, certErr
+		}
+		return nil, errMissingCredentials
+	}
+
+	for _, credential := range a.credentials {
+		if subtle.ConstantTimeCompare([]byte(token), credential.secret) == 1 {
+			return credential, nil
+		}
+	}
+	return nil, errInvalidAPIKey
+}
+
+func (a *AdminAuthenticator) certificateCredential(r *http.Request) (*adminCredential, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return nil, nil
+	}
+
+	for _, identity := range certificateIdentities(r.TLS.VerifiedChains[0][0]) {
+		if credential, exists := a.certCredentials[identity]; exists {
+			return credential, nil
+		}
+	}
+	return nil, errUnmappedCertificate
+}
+
+func (a *AdminAuthenticator) verifySignature(r *http.Request, keyID string) (*adminCredential, error) {
+	credential, exists := a.credentials[keyID]
+	if !exists {
+		return nil, errUnknownKeyID
+	}
+
+	timestamp := r.Header.Get(headerTimestamp)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errInvalidTimestamp
+	}
+
+	now := time.Now()
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-a.maxSkew)) || signedAt.After(now.Add(a.maxSkew)) {
+		return nil, errStaleTimestamp
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSignedBodySize {
+		return nil, errBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	signature, err := hex.DecodeString(r.Header.Get(headerSignature))
+	if err != nil || !hmac.Equal(signature, signRequest(credential.secret, timestamp, r.Method, r.URL.RequestURI(), body)) {
+		return nil, errInvalidSignature
+	}
+
+	if !a.markSignatureSeen(hex.EncodeToString(signature), signedAt, now) {
+		return nil, errReplayedSignature
+	}
+
+	return credential, nil
+}
+
+func (a *AdminAuthenticator) markSignatureSeen(signature string, signedAt, now time.Time) bool {
+	a.seenMu.Lock()
+	defer a.seenMu.Unlock()
+
+	for seen, expiresAt := range a.seenSignatures {
+		if now.After(expiresAt) {
+			delete(a.seenSignatures, seen)
+		}
+	}
+
+	if _, seen := a.seenSignatures[signature]; seen {
+		return false
+	}
+	a.seenSignatures[signature] = signedAt.Add(a.maxSkew)
+	return true
+}
+
+func (a *AdminAuthenticator) audit(r *http.Request, keyID, scope, reason string) {
+	logger.Warn("Admin API request rejected", logger.Fields{
+		"audit":       true,
+		"key_id":      keyID,
+		"scope":       scope,
+		"reason":      reason,
+		"method":      r.Method,
+		"path":        r.URL.Path,
+		"remote_addr": r.RemoteAddr,
+		"user_agent":  r.UserAgent(),
+		"client_cert": clientCertificateSubject(r),
+	})
+}
+
+func (c *adminCredential) allows(scope string) bool {
+	return c.scopes[ScopeAdmin] || c.scopes[scope]
+}
+
+func parseAdminCredential(entry string) (*adminCredential, error) {
+	id, rest, found := strings.Cut(entry, ":")
+	separator := strings.LastIndex(rest, ":")
+	if !found || id == "" || separator <= 0 {
+		return nil, fmt.Errorf("admin API key %q must have the form id:secret:scopes", id)
+	}
+
+	scopes, err := parseScopes(id, rest[separator+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &adminCredential{
+		id:     id,
+		secret: []byte(rest[:separator]),
+		scopes: scopes,
+	}, nil
+}
+
+func parseScopes(id, value string) (map[string]bool, error) {
+	scopes := make(map[string]bool)
+	for _, scope := range strings.Split(value, "|") {
+		switch scope {
+		case ScopeRead, ScopeSend, ScopeAdmin:
+			scopes[scope] = true
+		default:
+			return nil, fmt.Errorf("admin credential %q has unknown scope %q", id, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func certificateIdentities(certificate *x509.Certificate) []string {
+	identities := make([]string, 0, 1+len(certificate.URIs)+len(certificate.DNSNames))
+	for _, uri := range certificate.URIs {
+		identities = append(identities, uri.String())
+	}
+	identities = append(identities, certificate.DNSNames...)
+	if certificate.Subject.CommonName != "" {
+		identities = append(identities, certificate.Subject.CommonName)
+	}
+	return identities
+}
+
+func clientCertificateSubject(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ""
+	}
+	return r.TLS.PeerCertificates[0].Subject.String()
+}
+
+func signRequest(secret []byte, timestamp, method, requestURI string, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "\n" + method + "\n" + requestURI + "\n" + hex.EncodeToString(bodyHash[:])))
+	return mac.Sum(nil)
+}
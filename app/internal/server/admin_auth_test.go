@@ -0,0 +1,290 @@
+package server
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gomw-gw/app/internal/config"
+)
+
+const (
+	testSenderSecret = "s3cr:et"
+	testReaderSecret = "reader-secret"
+	testAdminSecret  = "admin-secret"
+)
+
+func newTestAuthenticator(t *testing.T) *AdminAuthenticator {
+	t.Helper()
+
+	auth, err := NewAdminAuthenticator(&config.AdminConfig{
+		APIKeys: []string{
+			"sender:" + testSenderSecret + ":send|read",
+			"reader:" + testReaderSecret + ":read",
+			"ops:" + testAdminSecret + ":admin",
+		},
+		HMACMaxSkew: 5 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth
+}
+
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Write(body)
+}
+
+type signedRequest struct {
+	keyID      string
+	secret     string
+	method     string
+	signedURI  string
+	signedBody string
+	requestURI string
+	body       string
+	timestamp  time.Time
+}
+
+func (s signedRequest) build() *http.Request {
+	timestamp := strconv.FormatInt(s.timestamp.Unix(), 10)
+	signature := signRequest([]byte(s.secret), timestamp, s.method, s.signedURI, []byte(s.signedBody))
+
+	r := httptest.NewRequest(s.method, s.requestURI, strings.NewReader(s.body))
+	r.Header.Set(headerKeyID, s.keyID)
+	r.Header.Set(headerTimestamp, timestamp)
+	r.Header.Set(headerSignature, hex.EncodeToString(signature))
+	return r
+}
+
+func validSignedRequest() signedRequest {
+	body := `{"connection_id":"abc","message":"hi"}`
+	return signedRequest{
+		keyID:      "sender",
+		secret:     testSenderSecret,
+		method:     http.MethodPost,
+		signedURI:  "/send?trace=1",
+		signedBody: body,
+		requestURI: "/send?trace=1",
+		body:       body,
+		timestamp:  time.Now(),
+	}
+}
+
+func TestRequireHMACSignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*signedRequest)
+		want   int
+	}{
+		{"valid signature", func(s *signedRequest) {}, http.StatusOK},
+		{"valid signature within skew", func(s *signedRequest) { s.timestamp = time.Now().Add(-4 * time.Minute) }, http.StatusOK},
+		{"tampered body", func(s *signedRequest) { s.body = `{"connection_id":"abc","message":"pwned"}` }, http.StatusUnauthorized},
+		{"tampered URI", func(s *signedRequest) { s.requestURI = "/send?trace=2" }, http.StatusUnauthorized},
+		{"wrong secret", func(s *signedRequest) { s.secret = testReaderSecret }, http.StatusUnauthorized},
+		{"stale timestamp", func(s *signedRequest) { s.timestamp = time.Now().Add(-10 * time.Minute) }, http.StatusUnauthorized},
+		{"future timestamp", func(s *signedRequest) { s.timestamp = time.Now().Add(10 * time.Minute) }, http.StatusUnauthorized},
+		{"unknown key id", func(s *signedRequest) { s.keyID = "nobody" }, http.StatusUnauthorized},
+		{"insufficient scope", func(s *signedRequest) { s.keyID, s.secret = "reader", testReaderSecret }, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestAuthenticator(t).Require(ScopeSend, echoHandler)
+
+			request := validSignedRequest()
+			tt.modify(&request)
+
+			w := httptest.NewRecorder()
+			handler(w, request.build())
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && w.Body.String() != request.body {
+				t.Fatalf("handler read body %q, want %q", w.Body.String(), request.body)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("missing WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestRequireHMACInvalidHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{"non-numeric timestamp", headerTimestamp, "yesterday"},
+		{"non-hex signature", headerSignature, "not-hex"},
+		{"empty signature", headerSignature, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestAuthenticator(t).Require(ScopeSend, echoHandler)
+
+			r := validSignedRequest().build()
+			r.Header.Set(tt.header, tt.value)
+
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestRequireHMACRejectsReplay(t *testing.T) {
+	handler := newTestAuthenticator(t).Require(ScopeSend, echoHandler)
+	request := validSignedRequest()
+
+	first := httptest.NewRecorder()
+	handler(first, request.build())
+	if first.Code != http.StatusOK {
+		t.Fatalf("first status = %d, want %d", first.Code, http.StatusOK)
+	}
+
+	replay := httptest.NewRecorder()
+	handler(replay, request.build())
+	if replay.Code != http.StatusUnauthorized {
+		t.Fatalf("replay status = %d, want %d", replay.Code, http.StatusUnauthorized)
+	}
+
+	request.timestamp = request.timestamp.Add(time.Second)
+	fresh := httptest.NewRecorder()
+	handler(fresh, request.build())
+	if fresh.Code != http.StatusOK {
+		t.Fatalf("re-signed status = %d, want %d", fresh.Code, http.StatusOK)
+	}
+}
+
+func TestRequireHMACBodyTooLarge(t *testing.T) {
+	handler := newTestAuthenticator(t).Require(ScopeSend, echoHandler)
+
+	request := validSignedRequest()
+	request.body = strings.Repeat("a", maxSignedBodySize+1)
+	request.signedBody = request.body
+
+	w := httptest.NewRecorder()
+	handler(w, request.build())
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestRequireBearerScopes(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		scope         string
+		want          int
+	}{
+		{"send key on send route", "Bearer " + testSenderSecret, ScopeSend, http.StatusOK},
+		{"send key on read route", "Bearer " + testSenderSecret, ScopeRead, http.StatusOK},
+		{"send key on admin route", "Bearer " + testSenderSecret, ScopeAdmin, http.StatusForbidden},
+		{"read key on send route", "Bearer " + testReaderSecret, ScopeSend, http.StatusForbidden},
+		{"admin key on send route", "Bearer " + testAdminSecret, ScopeSend, http.StatusOK},
+		{"admin key on admin route", "Bearer " + testAdminSecret, ScopeAdmin, http.StatusOK},
+		{"unknown token", "Bearer nope", ScopeRead, http.StatusUnauthorized},
+		{"key id instead of secret", "Bearer reader", ScopeRead, http.StatusUnauthorized},
+		{"missing scheme", testReaderSecret, ScopeRead, http.StatusUnauthorized},
+		{"no credentials", "", ScopeRead, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestAuthenticator(t).Require(tt.scope, echoHandler)
+
+			r := httptest.NewRequest(http.MethodGet, "/status", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireWithoutAuthenticator(t *testing.T) {
+	var auth *AdminAuthenticator
+
+	w := httptest.NewRecorder()
+	auth.Require(ScopeAdmin, echoHandler)(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestParseAdminCredential(t *testing.T) {
+	tests := []struct {
+		entry   string
+		id      string
+		secret  string
+		scopes  []string
+		wantErr bool
+	}{
+		{entry: "backend:secret:send", id: "backend", secret: "secret", scopes: []string{ScopeSend}},
+		{entry: "backend:se:cr:et:send|read", id: "backend", secret: "se:cr:et", scopes: []string{ScopeSend, ScopeRead}},
+		{entry: "ops:secret:admin", id: "ops", secret: "secret", scopes: []string{ScopeAdmin}},
+		{entry: "backend", wantErr: true},
+		{entry: "backend:secret", wantErr: true},
+		{entry: "backend::send", wantErr: true},
+		{entry: ":secret:send", wantErr: true},
+		{entry: "backend:secret:", wantErr: true},
+		{entry: "backend:secret:write", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			credential, err := parseAdminCredential(tt.entry)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseAdminCredential(%q) succeeded, want error", tt.entry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAdminCredential(%q) error = %v", tt.entry, err)
+			}
+
+			if credential.id != tt.id || string(credential.secret) != tt.secret {
+				t.Fatalf("credential = %q/%q, want %q/%q", credential.id, credential.secret, tt.id, tt.secret)
+			}
+			if len(credential.scopes) != len(tt.scopes) {
+				t.Fatalf("scopes = %v, want %v", credential.scopes, tt.scopes)
+			}
+			for _, scope := range tt.scopes {
+				if !credential.scopes[scope] {
+					t.Fatalf("scopes = %v, missing %q", credential.scopes, scope)
+				}
+			}
+		})
+	}
+}
+
+func TestNewAdminAuthenticatorRejectsDuplicateKeyIDs(t *testing.T) {
+	_, err := NewAdminAuthenticator(&config.AdminConfig{
+		APIKeys: []string{"backend:one:send", "backend:two:read"},
+	})
+	if err == nil {
+		t.Fatal("NewAdminAuthenticator() with duplicate key ids succeeded, want error")
+	}
+}
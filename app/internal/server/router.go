@@ -8,37 +8,74 @@ import (
 )
 
 type Router struct {
-	mux               *http.ServeMux
+	publicMux         *http.ServeMux
+	adminMux          *http.ServeMux
 	websocketHandler  *handlers.WebSocketHandler
 	messageHandler    *handlers.MessageHandler
 	infoHandler       *handlers.InfoHandler
+	connectionHandler *handlers.ConnectionHandler
+	channelHandler    *handlers.ChannelHandler
+	userHandler       *handlers.UserHandler
+	adminAuth         *AdminAuthenticator
 }
 
 func NewRouter(
 	wsHandler *handlers.WebSocketHandler,
 	msgHandler *handlers.MessageHandler,
 	infoHandler *handlers.InfoHandler,
+	connectionHandler *handlers.ConnectionHandler,
+	channelHandler *handlers.ChannelHandler,
+	userHandler *handlers.UserHandler,
+	adminAuth *AdminAuthenticator,
 ) *Router {
 	return &Router{
-		mux:              http.NewServeMux(),
-		websocketHandler: wsHandler,
-		messageHandler:   msgHandler,
-		infoHandler:      infoHandler,
+		publicMux:         http.NewServeMux(),
+		adminMux:          http.NewServeMux(),
+		websocketHandler:  wsHandler,
+		messageHandler:    msgHandler,
+		infoHandler:       infoHandler,
+		connectionHandler: connectionHandler,
+		channelHandler:    channelHandler,
+		userHandler:       userHandler,
+		adminAuth:         adminAuth,
 	}
 }
 
 func (r *Router) SetupRoutes() {
-	r.mux.HandleFunc("/ws", r.websocketHandler.HandleConnection)
-	r.mux.HandleFunc("/send", r.messageHandler.HandleSendMessage)
-	r.mux.HandleFunc("/env", r.infoHandler.HandleEnvironmentInfo)
-	r.mux.HandleFunc("/health", r.infoHandler.HandleHealthCheck)
-	r.mux.HandleFunc("/status", r.infoHandler.HandleConnectionStatus)
+	r.publicMux.HandleFunc("/ws", r.websocketHandler.HandleConnection)
+
+	r.adminMux.HandleFunc("/send", r.adminAuth.Require(ScopeSend, r.messageHandler.HandleSendMessage))
+	r.adminMux.HandleFunc("/send/batch", r.adminAuth.Require(ScopeSend, r.messageHandler.HandleBatchSendMessage))
+	r.adminMux.HandleFunc("/broadcast", r.adminAuth.Require(ScopeSend, r.messageHandler.HandleBroadcast))
+	r.adminMux.HandleFunc("/env", r.adminAuth.Require(ScopeRead, r.infoHandler.HandleEnvironmentInfo))
+	r.adminMux.HandleFunc("/health", r.infoHandler.HandleHealthCheck)
+	r.adminMux.HandleFunc("/status", r.adminAuth.Require(ScopeRead, r.infoHandler.HandleConnectionStatus))
+	r.adminMux.HandleFunc("GET /connections/{id}", r.adminAuth.Require(ScopeRead, r.connectionHandler.HandleGetConnection))
+	r.adminMux.HandleFunc("DELETE /connections/{id}", r.adminAuth.Require(ScopeAdmin, r.connectionHandler.HandleDeleteConnection))
+	r.adminMux.HandleFunc("GET /channels/{name}", r.adminAuth.Require(ScopeRead, r.channelHandler.HandleGetChannel))
+	r.adminMux.HandleFunc("POST /channels/{name}/subscribe", r.adminAuth.Require(ScopeAdmin, r.channelHandler.HandleSubscribe))
+	r.adminMux.HandleFunc("POST /channels/{name}/unsubscribe", r.adminAuth.Require(ScopeAdmin, r.channelHandler.HandleUnsubscribe))
+	r.adminMux.HandleFunc("POST /channels/{name}/send", r.adminAuth.Require(ScopeSend, r.channelHandler.HandleChannelSend))
+	r.adminMux.HandleFunc("POST /users/{id}/send", r.adminAuth.Require(ScopeSend, r.userHandler.HandleUserSend))
+	r.adminMux.HandleFunc("DELETE /users/{id}/connections", r.adminAuth.Require(ScopeAdmin, r.userHandler.HandleDeleteUserConnections))
 
 	logger.Info("Routes configured", logger.Fields{
-		"routes": []string{"/ws", "/send", "/env", "/health", "/status"},
+		"public_routes": []string{"/ws"},
+		"admin_routes":  []string{"/send", "/send/batch", "/broadcast", "/env", "/health", "/status", "/connections/{id}", "/channels/{name}", "/users/{id}"},
 	})
 }
 
+func (r *Router) PublicHandler() http.Handler {
+	return r.publicMux
+}
+
+func (r *Router) AdminHandler() http.Handler {
+	return r.adminMux
+}
+
 func (r *Router) GetHandler() http.Handler {
-	return r.mux
-} 
\ No newline at end of file
+	mux := http.NewServeMux()
+	mux.Handle("/ws", r.publicMux)
+	mux.Handle("/", r.adminMux)
+	return mux
+}
\ No newline at end of file
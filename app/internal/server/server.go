@@ -2,39 +2,91 @@ package server
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"gomw-gw/app/internal/config"
 	"gomw-gw/app/pkg/logger"
 )
 
-type Server struct {
+type Listener struct {
+	Name    string
+	Address string
+	TLS     *config.TLSConfig
+	Handler http.Handler
+}
+
+type listener struct {
+	Listener
 	httpServer *http.Server
-	config     *config.ServerConfig
 }
 
-func NewServer(cfg *config.ServerConfig, handler http.Handler) *Server {
-	return &Server{
-		httpServer: &http.Server{
-			Addr:         cfg.ListenAddress,
-			Handler:      handler,
-			ReadTimeout:  cfg.ReadTimeout,
-			WriteTimeout: cfg.WriteTimeout,
-		},
+type Server struct {
+	listeners []*listener
+	config    *config.ServerConfig
+	done      chan struct{}
+}
+
+func NewServer(cfg *config.ServerConfig, listeners ...Listener) *Server {
+	s := &Server{
 		config: cfg,
+		done:   make(chan struct{}),
+	}
+
+	for _, l := range listeners {
+		s.listeners = append(s.listeners, &listener{
+			Listener: l,
+			httpServer: &http.Server{
+				Addr:         l.Address,
+				Handler:      l.Handler,
+				ReadTimeout:  cfg.ReadTimeout,
+				WriteTimeout: cfg.WriteTimeout,
+			},
+		})
 	}
+
+	return s
 }
 
 func (s *Server) Start() error {
+	errs := make(chan error, len(s.listeners))
+	for _, l := range s.listeners {
+		go func() {
+			errs <- s.serve(l)
+		}()
+	}
+
+	for range s.listeners {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) serve(l *listener) error {
+	tlsEnabled := l.TLS != nil && l.TLS.Enabled()
+
 	logger.Info("Starting HTTP server", logger.Fields{
-		"listen_address": s.config.ListenAddress,
+		"listener":       l.Name,
+		"listen_address": l.Address,
 		"read_timeout":   s.config.ReadTimeout,
 		"write_timeout":  s.config.WriteTimeout,
+		"tls":            tlsEnabled,
 	})
 
-	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	var err error
+	if tlsEnabled {
+		err = s.listenAndServeTLS(l)
+	} else {
+		err = l.httpServer.ListenAndServe()
+	}
+
+	if err != nil && err != http.ErrServerClosed {
 		logger.Error("Server failed to start", logger.Fields{
-			"error": err.Error(),
+			"listener": l.Name,
+			"error":    err.Error(),
 		})
 		return err
 	}
@@ -42,16 +94,41 @@ func (s *Server) Start() error {
 	return nil
 }
 
+func (s *Server) listenAndServeTLS(l *listener) error {
+	reloader, err := NewCertReloader(l.TLS.CertFile, l.TLS.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := newTLSConfig(l.TLS, reloader)
+	if err != nil {
+		return err
+	}
+
+	l.httpServer.TLSConfig = tlsConfig
+	go reloader.Watch(l.TLS.ReloadInterval, s.done)
+
+	return l.httpServer.ListenAndServeTLS("", "")
+}
+
 func (s *Server) Shutdown(ctx context.Context) error {
 	logger.Info("Shutting down HTTP server", logger.Fields{})
+	close(s.done)
 
-	if err := s.httpServer.Shutdown(ctx); err != nil {
-		logger.Error("Server shutdown failed", logger.Fields{
-			"error": err.Error(),
-		})
-		return err
+	var errs []error
+	for _, l := range s.listeners {
+		if err := l.httpServer.Shutdown(ctx); err != nil {
+			logger.Error("Server shutdown failed", logger.Fields{
+				"listener": l.Name,
+				"error":    err.Error(),
+			})
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 
 	logger.Info("Server shutdown completed", logger.Fields{})
 	return nil
-} 
\ No newline at end of file
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"gomw-gw/app/internal/config"
+	"gomw-gw/app/internal/models"
+)
+
+var (
+	ErrConnectionNotFound       = errors.New("connection not found")
+	ErrSubscriptionLimitReached = errors.New("subscription limit reached")
+)
+
+type ChannelManager struct {
+	sessionManager   *SessionManager
+	maxSubscriptions int
+
+	mu            sync.RWMutex
+	channels      map[string]map[models.ConnectionID]struct{}
+	subscriptions map[models.ConnectionID]map[string]struct{}
+}
+
+func NewChannelManager(cfg *config.WebSocketConfig, sessionManager *SessionManager) *ChannelManager {
+	cm := &ChannelManager{
+		sessionManager:   sessionManager,
+		maxSubscriptions: cfg.MaxSubscriptions,
+		channels:         make(map[string]map[models.ConnectionID]struct{}),
+		subscriptions:    make(map[models.ConnectionID]map[string]struct{}),
+	}
+
+	sessionManager.OnSessionRemoved(func(session *models.Session) {
+		cm.RemoveConnection(session.ID)
+	})
+
+	return cm
+}
+
+func (cm *ChannelManager) Subscribe(channel string, connectionID models.ConnectionID) (bool, error) {
+	if _, exists := cm.sessionManager.GetSession(connectionID); !exists {
+		return false, ErrConnectionNotFound
+	}
+
+	cm.mu.Lock()
+	channels := cm.subscriptions[connectionID]
+	if _, subscribed := channels[channel]; !subscribed && cm.maxSubscriptions > 0 && len(channels) >= cm.maxSubscriptions {
+		cm.mu.Unlock()
+		return false, ErrSubscriptionLimitReached
+	}
+
+	members, exists := cm.channels[channel]
+	if !exists {
+		members = make(map[models.ConnectionID]struct{})
+		cm.channels[channel] = members
+	}
+	_, alreadySubscribed := members[connectionID]
+	members[connectionID] = struct{}{}
+
+	if channels == nil {
+		channels = make(map[string]struct{})
+		cm.subscriptions[connectionID] = channels
+	}
+	channels[channel] = struct{}{}
+	cm.mu.Unlock()
+
+	// The session may have been removed while we were subscribing it, in
+	// which case the removal listener has already run and would miss us.
+	if _, exists := cm.sessionManager.GetSession(connectionID); !exists {
+		cm.RemoveConnection(connectionID)
+		return false, ErrConnectionNotFound
+	}
+
+	return !alreadySubscribed, nil
+}
+
+func (cm *ChannelManager) Unsubscribe(channel string, connectionID models.ConnectionID) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	members, exists := cm.channels[channel]
+	if !exists {
+		return false
+	}
+	if _, subscribed := members[connectionID]; !subscribed {
+		return false
+	}
+
+	cm.removeMembershipLocked(channel, connectionID)
+	return true
+}
+
+func (cm *ChannelManager) RemoveConnection(connectionID models.ConnectionID) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for channel := range cm.subscriptions[connectionID] {
+		cm.removeMembershipLocked(channel, connectionID)
+	}
+}
+
+func (cm *ChannelManager) Members(channel string) []models.ConnectionID {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	members := make([]models.ConnectionID, 0, len(cm.channels[channel]))
+	for connectionID := range cm.channels[channel] {
+		members = append(members, connectionID)
+	}
+	return members
+}
+
+func (cm *ChannelManager) MemberSessions(channel string) []*models.Session {
+	members := cm.Members(channel)
+
+	sessions := make([]*models.Session, 0, len(members))
+	for _, connectionID := range members {
+		if session, exists := cm.sessionManager.GetSession(connectionID); exists {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+func (cm *ChannelManager) Channels(connectionID models.ConnectionID) []string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	channels := make([]string, 0, len(cm.subscriptions[connectionID]))
+	for channel := range cm.subscriptions[connectionID] {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+func (cm *ChannelManager) removeMembershipLocked(channel string, connectionID models.ConnectionID) {
+	if members, exists := cm.channels[channel]; exists {
+		delete(members, connectionID)
+		if len(members) == 0 {
+			delete(cm.channels, channel)
+		}
+	}
+
+	if channels, exists := cm.subscriptions[connectionID]; exists {
+		delete(channels, channel)
+		if len(channels) == 0 {
+			delete(cm.subscriptions, connectionID)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"gomw-gw/app/internal/config"
+	"gomw-gw/app/pkg/logger"
+)
+
+type ClientIPResolver struct {
+	trustedProxies []netip.Prefix
+}
+
+func NewClientIPResolver(cfg *config.WebSocketConfig) *ClientIPResolver {
+	resolver := &ClientIPResolver{}
+
+	for _, entry := range cfg.TrustedProxies {
+		prefix, err := parseTrustedProxy(entry)
+		if err != nil {
+			logger.Warn("Ignoring invalid trusted proxy", logger.Fields{
+				"trusted_proxy": entry,
+				"error":         err.Error(),
+			})
+			continue
+		}
+		resolver.trustedProxies = append(resolver.trustedProxies, prefix)
+	}
+
+	return resolver
+}
+
+func (c *ClientIPResolver) Resolve(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remote, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	remote = remote.Unmap()
+
+	if !c.trusted(remote) {
+		return remote.String()
+	}
+
+	hops := forwardedHops(r.Header)
+	if len(hops) == 0 {
+		if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+			return realIP.Unmap().String()
+		}
+		return remote.String()
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			break
+		}
+		client = hop.Unmap()
+		if !c.trusted(client) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+func (c *ClientIPResolver) trusted(addr netip.Addr) bool {
+	for _, prefix := range c.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func forwardedHops(header http.Header) []string {
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+func parseTrustedProxy(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
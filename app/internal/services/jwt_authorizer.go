@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gomw-gw/app/internal/config"
+	"gomw-gw/app/pkg/jwt"
+	"gomw-gw/app/pkg/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+var ErrMissingToken = errors.New("no token found in request")
+
+const (
+	tokenSourceQuery    = "query"
+	tokenSourceHeader   = "header"
+	tokenSourceCookie   = "cookie"
+	tokenSourceProtocol = "protocol"
+)
+
+type tokenSource struct {
+	kind string
+	name string
+}
+
+type JWTAuthorization struct {
+	Claims      jwt.Claims
+	Subprotocol string
+	QueryParam  string
+}
+
+type JWTAuthorizer struct {
+	verifier *jwt.Verifier
+	sources  []tokenSource
+}
+
+func NewJWTAuthorizer(cfg *config.JWTConfig) (*JWTAuthorizer, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	var keys *jwt.KeySet
+	if cfg.JWKSFile != "" {
+		keySet, err := jwt.LoadKeySet(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWKS file: %w", err)
+		}
+		for _, skipped := range keySet.Skipped() {
+			logger.Warn("Skipping unsupported JWKS key", logger.Fields{
+				"jwks_file": cfg.JWKSFile,
+				"key":       skipped,
+			})
+		}
+		keys = keySet
+	}
+
+	sources := make([]tokenSource, 0, len(cfg.TokenSources))
+	for _, entry := range cfg.TokenSources {
+		kind, name, _ := strings.Cut(entry, ":")
+		switch kind {
+		case tokenSourceQuery, tokenSourceHeader, tokenSourceCookie:
+			if name == "" {
+				return nil, fmt.Errorf("token source %q requires a name", entry)
+			}
+		case tokenSourceProtocol:
+		default:
+			return nil, fmt.Errorf("unknown token source %q", entry)
+		}
+		sources = append(sources, tokenSource{kind: kind, name: name})
+	}
+
+	return &JWTAuthorizer{
+		verifier: jwt.NewVerifier(jwt.Options{
+			Secret:   []byte(cfg.Secret),
+			Keys:     keys,
+			Issuer:   cfg.Issuer,
+			Audience: cfg.Audience,
+			Leeway:   cfg.Leeway,
+		}),
+		sources: sources,
+	}, nil
+}
+
+func (a *JWTAuthorizer) Authorize(r *http.Request) (*JWTAuthorization, error) {
+	token, source, subprotocol := a.extractToken(r)
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	claims, err := a.verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	authorization := &JWTAuthorization{
+		Claims:      claims,
+		Subprotocol: subprotocol,
+	}
+	if source.kind == tokenSourceQuery {
+		authorization.QueryParam = source.name
+	}
+	return authorization, nil
+}
+
+func (a *JWTAuthorizer) extractToken(r *http.Request) (string, tokenSource, string) {
+	for _, source := range a.sources {
+		switch source.kind {
+		case tokenSourceQuery:
+			if token := r.URL.Query().Get(source.name); token != "" {
+				return token, source, ""
+			}
+		case tokenSourceHeader:
+			if token := r.Header.Get(source.name); token != "" {
+				if scheme, credentials, found := strings.Cut(token, " "); found && strings.EqualFold(scheme, "Bearer") {
+					token = credentials
+				}
+				return strings.TrimSpace(token), source, ""
+			}
+		case tokenSourceCookie:
+			if cookie, err := r.Cookie(source.name); err == nil && cookie.Value != "" {
+				return cookie.Value, source, ""
+			}
+		case tokenSourceProtocol:
+			if token, subprotocol := tokenFromSubprotocols(websocket.Subprotocols(r)); token != "" {
+				return token, source, subprotocol
+			}
+		}
+	}
+	return "", tokenSource{}, ""
+}
+
+func tokenFromSubprotocols(protocols []string) (string, string) {
+	var token, subprotocol string
+	for _, protocol := range protocols {
+		if token == "" && jwt.LooksLikeToken(protocol) {
+			token = protocol
+		} else if subprotocol == "" {
+			subprotocol = protocol
+		}
+	}
+	return token, subprotocol
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gomw-gw/app/internal/config"
+	"gomw-gw/app/pkg/logger"
+)
+
+const (
+	DefaultRouteKey = "$default"
+
+	requestBodyPrefix         = "$request.body"
+	subprotocolRouteSeparator = "/"
+)
+
+type RouteIntegration struct {
+	URL    string
+	TwoWay bool
+}
+
+type MessageRouter struct {
+	selectionPaths map[string][]string
+	routes         map[string]*RouteIntegration
+}
+
+func NewMessageRouter(cfg *config.WebhookConfig) *MessageRouter {
+	routes := make(map[string]*RouteIntegration, len(cfg.Routes)+1)
+	if cfg.MessageURL != "" {
+		routes[DefaultRouteKey] = &RouteIntegration{URL: cfg.MessageURL}
+	}
+	for routeKey, url := range cfg.Routes {
+		routes[routeKey] = &RouteIntegration{URL: url}
+	}
+	for _, routeKey := range cfg.TwoWayRoutes {
+		if integration, exists := routes[routeKey]; exists {
+			integration.TwoWay = true
+		} else {
+			logger.Warn("Two-way route has no integration", logger.Fields{
+				"route_key": routeKey,
+			})
+		}
+	}
+
+	router := &MessageRouter{
+		selectionPaths: make(map[string][]string),
+		routes:         routes,
+	}
+
+	router.addSelectionExpression("", cfg.RouteSelectionExpression)
+	for subprotocol, expression := range cfg.SubprotocolRouteSelectionExpressions {
+		router.addSelectionExpression(subprotocol, expression)
+	}
+
+	return router
+}
+
+func (r *MessageRouter) addSelectionExpression(subprotocol, expression string) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return
+	}
+
+	path, err := parseSelectionExpression(expression)
+	if err != nil {
+		logger.Warn("Ignoring invalid route selection expression", logger.Fields{
+			"subprotocol": subprotocol,
+			"expression":  expression,
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	r.selectionPaths[subprotocol] = path
+}
+
+func (r *MessageRouter) SelectRoute(subprotocol string, data []byte) string {
+	path, exists := r.selectionPaths[subprotocol]
+	if !exists {
+		path = r.selectionPaths[""]
+	}
+	if len(path) == 0 {
+		return DefaultRouteKey
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return DefaultRouteKey
+	}
+
+	value := body
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return DefaultRouteKey
+		}
+		if value, ok = object[key]; !ok {
+			return DefaultRouteKey
+		}
+	}
+
+	var routeKey string
+	switch v := value.(type) {
+	case string:
+		routeKey = v
+	case float64, bool:
+		routeKey = fmt.Sprint(v)
+	default:
+		return DefaultRouteKey
+	}
+
+	if _, exists := r.lookup(subprotocol, routeKey); !exists {
+		return DefaultRouteKey
+	}
+
+	return routeKey
+}
+
+func (r *MessageRouter) Resolve(subprotocol, routeKey string) (*RouteIntegration, bool) {
+	integration, exists := r.lookup(subprotocol, routeKey)
+	if !exists || integration.URL == "" {
+		return nil, false
+	}
+	return integration, true
+}
+
+func (r *MessageRouter) lookup(subprotocol, routeKey string) (*RouteIntegration, bool) {
+	if subprotocol != "" {
+		if integration, exists := r.routes[subprotocol+subprotocolRouteSeparator+routeKey]; exists {
+			return integration, true
+		}
+	}
+
+	integration, exists := r.routes[routeKey]
+	return integration, exists
+}
+
+func parseSelectionExpression(expression string) ([]string, error) {
+	if !strings.HasPrefix(expression, requestBodyPrefix+".") {
+		return nil, fmt.Errorf("expression must start with %s", requestBodyPrefix)
+	}
+
+	path := strings.Split(strings.TrimPrefix(expression, requestBodyPrefix+"."), ".")
+	for _, key := range path {
+		if key == "" {
+			return nil, fmt.Errorf("expression contains an empty path segment")
+		}
+	}
+
+	return path, nil
+}
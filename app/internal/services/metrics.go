@@ -0,0 +1,42 @@
+package services
+
+import (
+	"sync"
+)
+
+const (
+	MetricOversizedMessages       = "oversized_messages"
+	MetricMessageQueueOverflow    = "message_queue_overflow"
+	MetricRateLimitedMessages     = "rate_limited_messages"
+	MetricRejectedConnectionLimit = "rejected_connection_limit"
+	MetricRejectedHandshakeRate   = "rejected_handshake_rate"
+	MetricRejectedOrigin          = "rejected_origin"
+)
+
+type Metrics struct {
+	mu       sync.Mutex
+	counters map[string]int64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		counters: make(map[string]int64),
+	}
+}
+
+func (m *Metrics) Increment(name string) {
+	m.mu.Lock()
+	m.counters[name]++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) Snapshot() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]int64, len(m.counters))
+	for name, value := range m.counters {
+		snapshot[name] = value
+	}
+	return snapshot
+}
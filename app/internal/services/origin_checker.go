@@ -0,0 +1,76 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+
+	"gomw-gw/app/internal/config"
+)
+
+type originRule struct {
+	scheme   string
+	host     string
+	wildcard bool
+}
+
+type OriginChecker struct {
+	allowAll   bool
+	allowEmpty bool
+	rules      []originRule
+}
+
+func NewOriginChecker(cfg *config.WebSocketConfig) *OriginChecker {
+	checker := &OriginChecker{
+		allowAll:   len(cfg.AllowedOrigins) == 0,
+		allowEmpty: cfg.AllowEmptyOrigin,
+	}
+
+	for _, entry := range cfg.AllowedOrigins {
+		entry = strings.TrimSuffix(strings.ToLower(entry), "/")
+		if entry == "*" {
+			checker.allowAll = true
+			continue
+		}
+
+		var rule originRule
+		if scheme, host, found := strings.Cut(entry, "://"); found {
+			rule.scheme, entry = scheme, host
+		}
+		if host, found := strings.CutPrefix(entry, "*."); found {
+			rule.wildcard, entry = true, host
+		}
+		rule.host = entry
+
+		checker.rules = append(checker.rules, rule)
+	}
+
+	return checker
+}
+
+func (c *OriginChecker) Allowed(origin string) bool {
+	if origin == "" {
+		return c.allowEmpty
+	}
+	if c.allowAll {
+		return true
+	}
+
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	for _, rule := range c.rules {
+		if rule.scheme != "" && rule.scheme != u.Scheme {
+			continue
+		}
+		if rule.wildcard && strings.HasSuffix(u.Host, "."+rule.host) {
+			return true
+		}
+		if !rule.wildcard && u.Host == rule.host {
+			return true
+		}
+	}
+
+	return false
+}
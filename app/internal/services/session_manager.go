@@ -1,22 +1,193 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 
+	"gomw-gw/app/internal/config"
 	"gomw-gw/app/internal/models"
+	"gomw-gw/app/pkg/logger"
+)
+
+const (
+	userIDSourceQuery   = "query"
+	userIDSourceContext = "context"
+	userIDSourceClaim   = "claim"
+)
+
+var (
+	ErrConnectionLimitReached   = errors.New("connection limit reached")
+	ErrIPConnectionLimitReached = errors.New("per-IP connection limit reached")
 )
 
 type SessionManager struct {
-	sessions sync.Map
-	mu       sync.RWMutex
+	sessions     sync.Map
+	mu           sync.RWMutex
+	sessionCount atomic.Int64
+
+	maxConnections      int
+	maxConnectionsPerIP int
+	reservationsMu      sync.Mutex
+	reservations        int
+	reservationsPerIP   map[string]int
+
+	listenersMu      sync.RWMutex
+	removedListeners []func(*models.Session)
+
+	userIDKind string
+	userIDName string
+	usersMu    sync.RWMutex
+	users      map[string]map[models.ConnectionID]struct{}
+	userIDs    map[models.ConnectionID]string
+}
+
+func NewSessionManager(cfg *config.WebSocketConfig) *SessionManager {
+	sm := &SessionManager{
+		maxConnections:      cfg.MaxConnections,
+		maxConnectionsPerIP: cfg.MaxConnectionsPerIP,
+		reservationsPerIP:   make(map[string]int),
+		users:               make(map[string]map[models.ConnectionID]struct{}),
+		userIDs:             make(map[models.ConnectionID]string),
+	}
+
+	if cfg.UserIDSource != "" {
+		kind, name, _ := strings.Cut(cfg.UserIDSource, ":")
+		validKind := kind == userIDSourceQuery || kind == userIDSourceContext || kind == userIDSourceClaim
+		if validKind && name != "" {
+			sm.userIDKind, sm.userIDName = kind, name
+		} else {
+			logger.Warn("Ignoring invalid user ID source", logger.Fields{
+				"user_id_source": cfg.UserIDSource,
+			})
+		}
+	}
+
+	return sm
+}
+
+func (sm *SessionManager) ReserveConnection(clientIP string) error {
+	sm.reservationsMu.Lock()
+	defer sm.reservationsMu.Unlock()
+
+	if sm.maxConnections > 0 && sm.reservations >= sm.maxConnections {
+		return ErrConnectionLimitReached
+	}
+	if sm.maxConnectionsPerIP > 0 && sm.reservationsPerIP[clientIP] >= sm.maxConnectionsPerIP {
+		return ErrIPConnectionLimitReached
+	}
+
+	sm.reservations++
+	sm.reservationsPerIP[clientIP]++
+	return nil
 }
 
-func NewSessionManager() *SessionManager {
-	return &SessionManager{}
+func (sm *SessionManager) ReleaseConnection(clientIP string) {
+	sm.reservationsMu.Lock()
+	defer sm.reservationsMu.Unlock()
+
+	if sm.reservations > 0 {
+		sm.reservations--
+	}
+	if sm.reservationsPerIP[clientIP] <= 1 {
+		delete(sm.reservationsPerIP, clientIP)
+		return
+	}
+	sm.reservationsPerIP[clientIP]--
 }
 
 func (sm *SessionManager) AddSession(session *models.Session) {
-	sm.sessions.Store(session.ID, session)
+	if _, loaded := sm.sessions.Swap(session.ID, session); !loaded {
+		sm.sessionCount.Add(1)
+	}
+	sm.RefreshUserIndex(session)
+}
+
+func (sm *SessionManager) RefreshUserIndex(session *models.Session) {
+	if sm.userIDKind == "" {
+		return
+	}
+
+	userID := sm.resolveUserID(session)
+
+	sm.usersMu.Lock()
+	defer sm.usersMu.Unlock()
+
+	if _, exists := sm.sessions.Load(session.ID); !exists {
+		return
+	}
+
+	if current, indexed := sm.userIDs[session.ID]; indexed {
+		if current == userID {
+			return
+		}
+		sm.unindexUserLocked(session.ID)
+	}
+
+	if userID == "" {
+		return
+	}
+
+	connections, exists := sm.users[userID]
+	if !exists {
+		connections = make(map[models.ConnectionID]struct{})
+		sm.users[userID] = connections
+	}
+	connections[session.ID] = struct{}{}
+	sm.userIDs[session.ID] = userID
+}
+
+func (sm *SessionManager) GetUserID(connectionID models.ConnectionID) string {
+	sm.usersMu.RLock()
+	defer sm.usersMu.RUnlock()
+
+	return sm.userIDs[connectionID]
+}
+
+func (sm *SessionManager) GetUserSessions(userID string) []*models.Session {
+	sm.usersMu.RLock()
+	connectionIDs := make([]models.ConnectionID, 0, len(sm.users[userID]))
+	for connectionID := range sm.users[userID] {
+		connectionIDs = append(connectionIDs, connectionID)
+	}
+	sm.usersMu.RUnlock()
+
+	sessions := make([]*models.Session, 0, len(connectionIDs))
+	for _, connectionID := range connectionIDs {
+		if session, exists := sm.GetSession(connectionID); exists {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+func (sm *SessionManager) resolveUserID(session *models.Session) string {
+	switch sm.userIDKind {
+	case userIDSourceQuery:
+		return session.QueryParams.Get(sm.userIDName)
+	case userIDSourceContext, userIDSourceClaim:
+		if value, exists := session.GetContext()[sm.userIDName]; exists && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+func (sm *SessionManager) unindexUserLocked(connectionID models.ConnectionID) {
+	userID, indexed := sm.userIDs[connectionID]
+	if !indexed {
+		return
+	}
+
+	delete(sm.userIDs, connectionID)
+	if connections, exists := sm.users[userID]; exists {
+		delete(connections, connectionID)
+		if len(connections) == 0 {
+			delete(sm.users, userID)
+		}
+	}
 }
 
 func (sm *SessionManager) GetSession(connectionID models.ConnectionID) (*models.Session, bool) {
@@ -30,12 +201,39 @@ func (sm *SessionManager) GetSession(connectionID models.ConnectionID) (*models.
 }
 
 func (sm *SessionManager) RemoveSession(connectionID models.ConnectionID) {
-	if session, exists := sm.GetSession(connectionID); exists {
-		session.Close()
-		sm.sessions.Delete(connectionID)
+	value, exists := sm.sessions.LoadAndDelete(connectionID)
+	if !exists {
+		return
+	}
+
+	sm.sessionCount.Add(-1)
+
+	session, ok := value.(*models.Session)
+	if !ok {
+		return
+	}
+	session.Close()
+	sm.ReleaseConnection(session.ClientIP)
+
+	sm.usersMu.Lock()
+	sm.unindexUserLocked(connectionID)
+	sm.usersMu.Unlock()
+
+	sm.listenersMu.RLock()
+	defer sm.listenersMu.RUnlock()
+
+	for _, listener := range sm.removedListeners {
+		listener(session)
 	}
 }
 
+func (sm *SessionManager) OnSessionRemoved(listener func(*models.Session)) {
+	sm.listenersMu.Lock()
+	defer sm.listenersMu.Unlock()
+
+	sm.removedListeners = append(sm.removedListeners, listener)
+}
+
 func (sm *SessionManager) GetAllSessions() []*models.Session {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
@@ -52,10 +250,5 @@ func (sm *SessionManager) GetAllSessions() []*models.Session {
 }
 
 func (sm *SessionManager) GetSessionCount() int {
-	count := 0
-	sm.sessions.Range(func(key, value interface{}) bool {
-		count++
-		return true
-	})
-	return count
-} 
\ No newline at end of file
+	return int(sm.sessionCount.Load())
+}
\ No newline at end of file
@@ -3,48 +3,167 @@ package services
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	"gomw-gw/app/internal/config"
 	"gomw-gw/app/internal/models"
 	"gomw-gw/app/pkg/logger"
 	"gomw-gw/app/pkg/network"
+
+	"github.com/gorilla/websocket"
+)
+
+const maxWebhookResponseSize = 1 << 20
+
+var (
+	errOnConnectSaturated      = errors.New("no onconnect webhook slot available")
+	errWebhookResponseTooLarge = errors.New("webhook response exceeds maximum size")
 )
 
+type webhookResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+type messageJob struct {
+	url      string
+	payload  *models.MessagePayload
+	routeKey string
+	twoWay   bool
+}
+
+type AuthorizationResult struct {
+	Allowed    bool
+	StatusCode int
+	Throttled  bool
+}
+
 type WebhookService struct {
-	httpClient *http.Client
-	config     *config.WebhookConfig
-	serverInfo *network.ServerInfo
+	httpClient    *http.Client
+	config        *config.WebhookConfig
+	serverInfo    *network.ServerInfo
+	messageRouter *MessageRouter
+	sessions      *SessionManager
+
+	onConnectSlots chan struct{}
+
+	messageQueuesMu sync.Mutex
+	messageQueues   map[models.ConnectionID]chan messageJob
+
+	contextListenersMu sync.RWMutex
+	contextListeners   []func(*models.Session)
+}
+
+func NewWebhookService(cfg *config.WebhookConfig, serverConfig *config.ServerConfig, sessionManager *SessionManager) *WebhookService {
+	ws := &WebhookService{
+		httpClient:    &http.Client{},
+		config:        cfg,
+		serverInfo:    network.GetServerInfo(serverConfig.ListenAddress),
+		messageRouter: NewMessageRouter(cfg),
+		sessions:      sessionManager,
+		messageQueues: make(map[models.ConnectionID]chan messageJob),
+	}
+
+	if cfg.OnConnectMaxInFlight > 0 {
+		ws.onConnectSlots = make(chan struct{}, cfg.OnConnectMaxInFlight)
+	}
+
+	sessionManager.OnSessionRemoved(ws.closeMessageQueue)
+
+	return ws
+}
+
+func (ws *WebhookService) AuthorizeConnection(session *models.Session, headers http.Header) *AuthorizationResult {
+	if !ws.config.AuthorizerEnabled || ws.config.OnConnectURL == "" {
+		return &AuthorizationResult{Allowed: true}
+	}
+
+	payload := &models.WebhookPayload{
+		ConnectionID: session.ID,
+		ClientIP:     session.ClientIP,
+		Subprotocol:  session.Subprotocol,
+		QueryParams:  session.QueryParams,
+		Headers:      headers,
+		Context:      session.GetContext(),
+		Timestamp:    session.ConnectedAt,
+		ServerIP:     ws.serverInfo.IP,
+		ServerPort:   ws.serverInfo.Port,
+	}
+
+	resp, err := ws.invokeOnConnectWebhook(payload)
+	if errors.Is(err, errOnConnectSaturated) {
+		logger.Warn("Authorizer webhook slots exhausted", logger.Fields{
+			"connection_id": string(session.ID),
+			"max_in_flight": ws.config.OnConnectMaxInFlight,
+		})
+		return &AuthorizationResult{StatusCode: http.StatusServiceUnavailable, Throttled: true}
+	}
+	if err != nil {
+		logger.Warn("Authorizer webhook call failed", logger.Fields{
+			"connection_id": string(session.ID),
+			"url":           ws.config.OnConnectURL,
+			"fail_open":     ws.config.AuthorizerFailOpen,
+			"error":         err.Error(),
+		})
+
+		if ws.config.AuthorizerFailOpen {
+			return &AuthorizationResult{Allowed: true}
+		}
+		return &AuthorizationResult{StatusCode: http.StatusServiceUnavailable}
+	}
+
+	if !resp.isSuccess() {
+		return &AuthorizationResult{StatusCode: rejectionStatus(resp.StatusCode)}
+	}
+
+	session.MergeContext(parseSessionContext(resp.Body))
+
+	return &AuthorizationResult{Allowed: true, StatusCode: resp.StatusCode}
 }
 
-func NewWebhookService(cfg *config.WebhookConfig, serverConfig *config.ServerConfig) *WebhookService {
-	return &WebhookService{
-		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
-		},
-		config:     cfg,
-		serverInfo: network.GetServerInfo(serverConfig.ListenAddress),
+func (ws *WebhookService) AuthorizeSubscription(session *models.Session, channel string) bool {
+	if ws.config.SubscribeAuthURL == "" {
+		return true
+	}
+
+	payload := &models.SubscriptionPayload{
+		ConnectionID: session.ID,
+		ClientIP:     session.ClientIP,
+		Subprotocol:  session.Subprotocol,
+		Channel:      channel,
+		Context:      session.GetContext(),
+		Timestamp:    time.Now(),
+		ServerIP:     ws.serverInfo.IP,
+		ServerPort:   ws.serverInfo.Port,
 	}
+
+	resp := ws.callWebhook(ws.config.SubscribeAuthURL, payload, "subscription")
+	return resp != nil && resp.isSuccess()
 }
 
 func (ws *WebhookService) NotifyConnection(session *models.Session) {
-	if ws.config.OnConnectURL == "" {
+	if ws.config.OnConnectURL == "" || ws.config.AuthorizerEnabled {
 		return
 	}
 
 	payload := &models.WebhookPayload{
 		ConnectionID: session.ID,
 		ClientIP:     session.ClientIP,
+		Subprotocol:  session.Subprotocol,
 		QueryParams:  session.QueryParams,
+		Context:      session.GetContext(),
 		Timestamp:    session.ConnectedAt,
 		ServerIP:     ws.serverInfo.IP,
 		ServerPort:   ws.serverInfo.Port,
 	}
 
-	go ws.callWebhook(ws.config.OnConnectURL, payload, "connection")
+	go ws.storeConnectionContext(session, payload)
 }
 
 func (ws *WebhookService) NotifyDisconnection(session *models.Session) {
@@ -52,9 +171,15 @@ func (ws *WebhookService) NotifyDisconnection(session *models.Session) {
 		return
 	}
 
+	reason, closeCode := session.DisconnectReason()
+
 	payload := &models.WebhookPayload{
 		ConnectionID: session.ID,
 		ClientIP:     session.ClientIP,
+		Subprotocol:  session.Subprotocol,
+		Context:      session.GetContext(),
+		Reason:       reason,
+		CloseCode:    closeCode,
 		Timestamp:    time.Now(),
 		ServerIP:     ws.serverInfo.IP,
 		ServerPort:   ws.serverInfo.Port,
@@ -63,44 +188,215 @@ func (ws *WebhookService) NotifyDisconnection(session *models.Session) {
 	go ws.callWebhook(ws.config.OnDisconnectURL, payload, "disconnection")
 }
 
-func (ws *WebhookService) callWebhook(url string, payload interface{}, eventType string) {
-	ctx, cancel := context.WithTimeout(context.Background(), ws.config.Timeout)
-	defer cancel()
+func (ws *WebhookService) NotifyMessage(session *models.Session, messageType int, data []byte) bool {
+	routeKey := DefaultRouteKey
+	if messageType == websocket.TextMessage {
+		routeKey = ws.messageRouter.SelectRoute(session.Subprotocol, data)
+	}
 
-	jsonData, err := json.Marshal(payload)
+	integration, exists := ws.messageRouter.Resolve(session.Subprotocol, routeKey)
+	if !exists {
+		logger.Debug("No route integration for message", logger.Fields{
+			"connection_id": string(session.ID),
+			"subprotocol":   session.Subprotocol,
+			"route_key":     routeKey,
+		})
+		return true
+	}
+
+	payload := &models.MessagePayload{
+		ConnectionID: session.ID,
+		ClientIP:     session.ClientIP,
+		Subprotocol:  session.Subprotocol,
+		RouteKey:     routeKey,
+		Context:      session.GetContext(),
+		Timestamp:    time.Now(),
+		ServerIP:     ws.serverInfo.IP,
+		ServerPort:   ws.serverInfo.Port,
+	}
+
+	if messageType == websocket.BinaryMessage {
+		payload.MessageType = "binary"
+		payload.Body = base64.StdEncoding.EncodeToString(data)
+		payload.IsBase64Encoded = true
+	} else {
+		payload.MessageType = "text"
+		payload.Body = string(data)
+	}
+
+	return ws.enqueueMessage(session, messageJob{
+		url:      integration.URL,
+		payload:  payload,
+		routeKey: routeKey,
+		twoWay:   integration.TwoWay,
+	})
+}
+
+func (ws *WebhookService) enqueueMessage(session *models.Session, job messageJob) bool {
+	ws.messageQueuesMu.Lock()
+	defer ws.messageQueuesMu.Unlock()
+
+	queue, exists := ws.messageQueues[session.ID]
+	if !exists {
+		if _, live := ws.sessions.GetSession(session.ID); !live {
+			return true
+		}
+
+		queueSize := ws.config.MessageQueueSize
+		if queueSize < 1 {
+			queueSize = 1
+		}
+		queue = make(chan messageJob, queueSize)
+		ws.messageQueues[session.ID] = queue
+		go ws.runMessageQueue(session, queue)
+	}
+
+	select {
+	case queue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+func (ws *WebhookService) runMessageQueue(session *models.Session, queue <-chan messageJob) {
+	for job := range queue {
+		if job.twoWay {
+			ws.relayResponse(session, job.url, job.payload, job.routeKey)
+			continue
+		}
+		ws.callWebhook(job.url, job.payload, "message")
+	}
+}
+
+func (ws *WebhookService) closeMessageQueue(session *models.Session) {
+	ws.messageQueuesMu.Lock()
+	defer ws.messageQueuesMu.Unlock()
+
+	if queue, exists := ws.messageQueues[session.ID]; exists {
+		delete(ws.messageQueues, session.ID)
+		close(queue)
+	}
+}
+
+func (ws *WebhookService) storeConnectionContext(session *models.Session, payload *models.WebhookPayload) {
+	ctx, cancel := context.WithTimeout(context.Background(), ws.config.Timeout)
+	err := ws.acquireOnConnectSlot(ctx)
+	cancel()
 	if err != nil {
-		logger.Error("Failed to marshal webhook payload", logger.Fields{
-			"event_type": eventType,
-			"error":      err.Error(),
+		logger.Warn("Skipping connection webhook", logger.Fields{
+			"connection_id": string(session.ID),
+			"error":         err.Error(),
 		})
 		return
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if _, exists := ws.sessions.GetSession(session.ID); !exists {
+		ws.releaseOnConnectSlot()
+		logger.Info("Skipping connection webhook for closed session", logger.Fields{
+			"connection_id": string(session.ID),
+		})
+		return
+	}
+
+	resp := ws.callWebhook(ws.config.OnConnectURL, payload, "connection")
+	ws.releaseOnConnectSlot()
+
+	if resp == nil || !resp.isSuccess() {
+		return
+	}
+
+	context := parseSessionContext(resp.Body)
+	if len(context) == 0 {
+		return
+	}
+
+	session.MergeContext(context)
+
+	ws.contextListenersMu.RLock()
+	defer ws.contextListenersMu.RUnlock()
+
+	for _, listener := range ws.contextListeners {
+		listener(session)
+	}
+}
+
+func (ws *WebhookService) invokeOnConnectWebhook(payload interface{}) (*webhookResponse, error) {
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), ws.config.AuthorizerTimeout)
+	err := ws.acquireOnConnectSlot(waitCtx)
+	cancelWait()
 	if err != nil {
-		logger.Error("Failed to create webhook request", logger.Fields{
-			"event_type": eventType,
-			"url":        url,
-			"error":      err.Error(),
+		return nil, err
+	}
+	defer ws.releaseOnConnectSlot()
+
+	ctx, cancel := context.WithTimeout(context.Background(), ws.config.AuthorizerTimeout)
+	defer cancel()
+
+	return ws.invokeWebhook(ctx, ws.config.OnConnectURL, payload)
+}
+
+func (ws *WebhookService) acquireOnConnectSlot(ctx context.Context) error {
+	if ws.onConnectSlots == nil {
+		return nil
+	}
+
+	select {
+	case ws.onConnectSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return errOnConnectSaturated
+	}
+}
+
+func (ws *WebhookService) releaseOnConnectSlot() {
+	if ws.onConnectSlots != nil {
+		<-ws.onConnectSlots
+	}
+}
+
+func (ws *WebhookService) OnSessionContextUpdated(listener func(*models.Session)) {
+	ws.contextListenersMu.Lock()
+	defer ws.contextListenersMu.Unlock()
+
+	ws.contextListeners = append(ws.contextListeners, listener)
+}
+
+func (ws *WebhookService) relayResponse(session *models.Session, url string, payload interface{}, routeKey string) {
+	resp := ws.callWebhook(url, payload, "message")
+	if resp == nil || !resp.isSuccess() || len(resp.Body) == 0 {
+		return
+	}
+
+	if status := session.Enqueue(websocket.TextMessage, resp.Body); status != models.SendStatusSent && status != models.SendStatusDroppedOldest {
+		logger.Warn("Failed to relay route response to WebSocket", logger.Fields{
+			"connection_id": string(session.ID),
+			"route_key":     routeKey,
+			"status":        status,
 		})
 		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "gomw-gw/1.0")
+	logger.Debug("Route response relayed", logger.Fields{
+		"connection_id": string(session.ID),
+		"route_key":     routeKey,
+		"message_size":  len(resp.Body),
+	})
+}
 
-	resp, err := ws.httpClient.Do(req)
+func (ws *WebhookService) callWebhook(url string, payload interface{}, eventType string) *webhookResponse {
+	ctx, cancel := context.WithTimeout(context.Background(), ws.config.Timeout)
+	defer cancel()
+
+	resp, err := ws.invokeWebhook(ctx, url, payload)
 	if err != nil {
 		logger.Warn("Webhook call failed", logger.Fields{
 			"event_type": eventType,
 			"url":        url,
 			"error":      err.Error(),
 		})
-		return
+		return nil
 	}
-	defer resp.Body.Close()
-
-	io.Copy(io.Discard, resp.Body)
 
 	if resp.StatusCode >= 400 {
 		logger.Warn("Webhook returned error status", logger.Fields{
@@ -108,7 +404,7 @@ func (ws *WebhookService) callWebhook(url string, payload interface{}, eventType
 			"url":         url,
 			"status_code": resp.StatusCode,
 		})
-		return
+		return resp
 	}
 
 	logger.Debug("Webhook call successful", logger.Fields{
@@ -116,4 +412,69 @@ func (ws *WebhookService) callWebhook(url string, payload interface{}, eventType
 		"url":         url,
 		"status_code": resp.StatusCode,
 	})
-} 
\ No newline at end of file
+	return resp
+}
+
+func (ws *WebhookService) invokeWebhook(ctx context.Context, url string, payload interface{}) (*webhookResponse, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gomw-gw/1.0")
+
+	resp, err := ws.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxWebhookResponseSize {
+		return nil, errWebhookResponseTooLarge
+	}
+
+	return &webhookResponse{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}, nil
+}
+
+func parseSessionContext(body []byte) map[string]interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var response struct {
+		Context map[string]interface{} `json:"context"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+
+	return response.Context
+}
+
+func rejectionStatus(statusCode int) int {
+	switch {
+	case statusCode >= 400 && statusCode < 600:
+		return statusCode
+	case statusCode >= 100 && statusCode < 400:
+		return http.StatusForbidden
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func (r *webhookResponse) isSuccess() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
@@ -0,0 +1,174 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+var errUnsupportedKey = errors.New("unsupported key")
+
+type KeySet struct {
+	keys    []jsonWebKey
+	skipped []string
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+
+	publicKey crypto.PublicKey
+}
+
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeySet(data)
+}
+
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+
+	keySet := &KeySet{}
+	for i := range document.Keys {
+		key := document.Keys[i]
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := key.parsePublicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			keySet.skipped = append(keySet.skipped, fmt.Sprintf("key %q: %s", key.KeyID, err))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", key.KeyID, err)
+		}
+		key.publicKey = publicKey
+		keySet.keys = append(keySet.keys, key)
+	}
+
+	if len(keySet.keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+
+	return keySet, nil
+}
+
+func (ks *KeySet) Skipped() []string {
+	if ks == nil {
+		return nil
+	}
+	return ks.skipped
+}
+
+func (ks *KeySet) Len() int {
+	if ks == nil {
+		return 0
+	}
+	return len(ks.keys)
+}
+
+func (ks *KeySet) lookup(keyID, algorithm string) crypto.PublicKey {
+	if ks == nil {
+		return nil
+	}
+
+	for _, key := range ks.keys {
+		if keyID != "" && key.KeyID != keyID {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != algorithm {
+			continue
+		}
+		if key.matches(algorithm) {
+			return key.publicKey
+		}
+	}
+	return nil
+}
+
+func (k *jsonWebKey) matches(algorithm string) bool {
+	switch algorithm {
+	case "RS256":
+		return k.KeyType == "RSA"
+	case "ES256":
+		return k.KeyType == "EC" && k.Curve == "P-256"
+	}
+	return false
+}
+
+func (k *jsonWebKey) parsePublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if k.Curve != "P-256" {
+			return nil, fmt.Errorf("%w: curve %q", errUnsupportedKey, k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		point := make([]byte, 65)
+		point[0] = 4
+		if x.BitLen() > 256 || y.BitLen() > 256 {
+			return nil, fmt.Errorf("point is not on curve %s", k.Curve)
+		}
+		x.FillBytes(point[1:33])
+		y.FillBytes(point[33:])
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("point is not on curve %s", k.Curve)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: key type %q", errUnsupportedKey, k.KeyType)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
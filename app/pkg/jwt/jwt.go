@@ -0,0 +1,187 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformedToken       = errors.New("malformed token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrKeyNotFound          = errors.New("no verification key for token")
+	ErrInvalidSignature     = errors.New("invalid token signature")
+	ErrTokenExpired         = errors.New("token is expired")
+	ErrTokenNotYetValid     = errors.New("token is not valid yet")
+	ErrInvalidIssuer        = errors.New("invalid token issuer")
+	ErrInvalidAudience      = errors.New("invalid token audience")
+)
+
+type Claims map[string]interface{}
+
+type Options struct {
+	Secret   []byte
+	Keys     *KeySet
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+type Verifier struct {
+	options Options
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+func NewVerifier(options Options) *Verifier {
+	return &Verifier{
+		options: options,
+	}
+}
+
+func LooksLikeToken(value string) bool {
+	return strings.Count(value, ".") == 2 && !strings.ContainsAny(value, " ,")
+}
+
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if err := v.verifySignature(&h, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) verifySignature(h *header, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch h.Algorithm {
+	case "HS256":
+		if len(v.options.Secret) == 0 {
+			return ErrKeyNotFound
+		}
+		mac := hmac.New(sha256.New, v.options.Secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case "RS256":
+		key, ok := v.options.Keys.lookup(h.KeyID, h.Algorithm).(*rsa.PublicKey)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case "ES256":
+		key, ok := v.options.Keys.lookup(h.KeyID, h.Algorithm).(*ecdsa.PublicKey)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		if len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, h.Algorithm)
+	}
+}
+
+func (v *Verifier) validateClaims(claims Claims) error {
+	now := time.Now()
+
+	if exp, ok := numericClaim(claims, "exp"); ok && !now.Before(exp.Add(v.options.Leeway)) {
+		return ErrTokenExpired
+	}
+
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(v.options.Leeway).Before(nbf) {
+		return ErrTokenNotYetValid
+	}
+
+	if v.options.Issuer != "" {
+		if issuer, _ := claims["iss"].(string); issuer != v.options.Issuer {
+			return ErrInvalidIssuer
+		}
+	}
+
+	if v.options.Audience != "" && !hasAudience(claims["aud"], v.options.Audience) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+func numericClaim(claims Claims, name string) (time.Time, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+func hasAudience(value interface{}, audience string) bool {
+	switch aud := value.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, entry := range aud {
+			if entry == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
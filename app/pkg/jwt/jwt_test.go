@@ -0,0 +1,284 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test-secret")
+
+type testKeys struct {
+	rsa  *rsa.PrivateKey
+	ec   *ecdsa.PrivateKey
+	keys *KeySet
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rsa","alg":"RS256","use":"sig","n":%q,"e":%q},
+		{"kty":"EC","kid":"ec","crv":"P-256","x":%q,"y":%q}
+	]}`,
+		encode(rsaKey.N.Bytes()), encode([]byte{1, 0, 1}),
+		encode(ecKey.X.FillBytes(make([]byte, 32))), encode(ecKey.Y.FillBytes(make([]byte, 32))),
+	)
+
+	keys, err := ParseKeySet([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKeys{rsa: rsaKey, ec: ecKey, keys: keys}
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signingInput(t *testing.T, h map[string]string, claims Claims) string {
+	t.Helper()
+
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encode(headerJSON) + "." + encode(claimsJSON)
+}
+
+func (k *testKeys) sign(t *testing.T, algorithm, keyID string, claims Claims) string {
+	t.Helper()
+
+	input := signingInput(t, map[string]string{"alg": algorithm, "kid": keyID}, claims)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch algorithm {
+	case "HS256":
+		mac := hmac.New(sha256.New, testSecret)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case "RS256":
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	default:
+		t.Fatalf("unsupported test algorithm %q", algorithm)
+	}
+
+	return input + "." + encode(signature)
+}
+
+func futureClaims() Claims {
+	return Claims{"sub": "user-1", "exp": float64(time.Now().Add(time.Hour).Unix())}
+}
+
+func TestVerifyValidTokens(t *testing.T) {
+	k := newTestKeys(t)
+	verifier := NewVerifier(Options{Secret: testSecret, Keys: k.keys})
+
+	tests := []struct {
+		algorithm string
+		keyID     string
+	}{
+		{"HS256", ""},
+		{"RS256", "rsa"},
+		{"RS256", ""},
+		{"ES256", "ec"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm+"/"+tt.keyID, func(t *testing.T) {
+			claims, err := verifier.Verify(k.sign(t, tt.algorithm, tt.keyID, futureClaims()))
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if claims["sub"] != "user-1" {
+				t.Fatalf("sub = %v, want user-1", claims["sub"])
+			}
+		})
+	}
+}
+
+func TestVerifyTamperedSignature(t *testing.T) {
+	k := newTestKeys(t)
+	verifier := NewVerifier(Options{Secret: testSecret, Keys: k.keys})
+
+	for _, algorithm := range []string{"HS256", "RS256", "ES256"} {
+		t.Run(algorithm, func(t *testing.T) {
+			token := k.sign(t, algorithm, "", futureClaims())
+
+			dot := strings.LastIndex(token, ".")
+			forged := signingInput(t, map[string]string{"alg": algorithm}, Claims{"sub": "admin"})
+			tampered := forged + token[dot:]
+
+			if _, err := verifier.Verify(tampered); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("Verify(tampered payload) error = %v, want %v", err, ErrInvalidSignature)
+			}
+
+			signature, err := base64.RawURLEncoding.DecodeString(token[dot+1:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			signature[0] ^= 0xff
+			flipped := token[:dot+1] + encode(signature)
+
+			if _, err := verifier.Verify(flipped); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("Verify(flipped signature) error = %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsAlgorithmConfusion(t *testing.T) {
+	k := newTestKeys(t)
+
+	t.Run("none", func(t *testing.T) {
+		verifier := NewVerifier(Options{Secret: testSecret, Keys: k.keys})
+		token := signingInput(t, map[string]string{"alg": "none"}, futureClaims()) + "."
+
+		if _, err := verifier.Verify(token); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Fatalf("Verify() error = %v, want %v", err, ErrUnsupportedAlgorithm)
+		}
+	})
+
+	t.Run("HS256 without secret", func(t *testing.T) {
+		verifier := NewVerifier(Options{Keys: k.keys})
+
+		if _, err := verifier.Verify(k.sign(t, "HS256", "", futureClaims())); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("Verify() error = %v, want %v", err, ErrKeyNotFound)
+		}
+	})
+
+	t.Run("RS256 with EC key id", func(t *testing.T) {
+		verifier := NewVerifier(Options{Keys: k.keys})
+
+		if _, err := verifier.Verify(k.sign(t, "RS256", "ec", futureClaims())); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("Verify() error = %v, want %v", err, ErrKeyNotFound)
+		}
+	})
+
+	t.Run("ES256 with RSA key id", func(t *testing.T) {
+		verifier := NewVerifier(Options{Keys: k.keys})
+
+		if _, err := verifier.Verify(k.sign(t, "ES256", "rsa", futureClaims())); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("Verify() error = %v, want %v", err, ErrKeyNotFound)
+		}
+	})
+}
+
+func TestVerifyTimeClaims(t *testing.T) {
+	k := newTestKeys(t)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		claims Claims
+		leeway time.Duration
+		want   error
+	}{
+		{"expired", Claims{"exp": float64(now.Add(-time.Minute).Unix())}, 0, ErrTokenExpired},
+		{"expired within leeway", Claims{"exp": float64(now.Add(-10 * time.Second).Unix())}, 30 * time.Second, nil},
+		{"expired beyond leeway", Claims{"exp": float64(now.Add(-time.Minute).Unix())}, 30 * time.Second, ErrTokenExpired},
+		{"not yet valid", Claims{"nbf": float64(now.Add(time.Minute).Unix())}, 0, ErrTokenNotYetValid},
+		{"not yet valid within leeway", Claims{"nbf": float64(now.Add(10 * time.Second).Unix())}, 30 * time.Second, nil},
+		{"not yet valid beyond leeway", Claims{"nbf": float64(now.Add(time.Minute).Unix())}, 30 * time.Second, ErrTokenNotYetValid},
+		{"no time claims", Claims{}, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier(Options{Secret: testSecret, Leeway: tt.leeway})
+
+			if _, err := verifier.Verify(k.sign(t, "HS256", "", tt.claims)); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyIssuerAndAudience(t *testing.T) {
+	k := newTestKeys(t)
+	verifier := NewVerifier(Options{Secret: testSecret, Issuer: "https://issuer.example", Audience: "gomw-gw"})
+
+	tests := []struct {
+		name   string
+		claims Claims
+		want   error
+	}{
+		{"string audience", Claims{"iss": "https://issuer.example", "aud": "gomw-gw"}, nil},
+		{"array audience", Claims{"iss": "https://issuer.example", "aud": []string{"other", "gomw-gw"}}, nil},
+		{"wrong string audience", Claims{"iss": "https://issuer.example", "aud": "other"}, ErrInvalidAudience},
+		{"wrong array audience", Claims{"iss": "https://issuer.example", "aud": []string{"other"}}, ErrInvalidAudience},
+		{"missing audience", Claims{"iss": "https://issuer.example"}, ErrInvalidAudience},
+		{"wrong issuer", Claims{"iss": "https://evil.example", "aud": "gomw-gw"}, ErrInvalidIssuer},
+		{"missing issuer", Claims{"aud": "gomw-gw"}, ErrInvalidIssuer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(k.sign(t, "HS256", "", tt.claims)); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeySetSkipsUnsupportedKeys(t *testing.T) {
+	document := `{"keys":[
+		{"kty":"EC","kid":"p384","crv":"P-384","x":"AA","y":"AA"},
+		{"kty":"oct","kid":"oct","k":"c2VjcmV0"},
+		{"kty":"OKP","kid":"okp","crv":"Ed25519","x":"AA"},
+		{"kty":"RSA","kid":"rsa","n":"AQAB","e":"AQAB"}
+	]}`
+
+	keys, err := ParseKeySet([]byte(document))
+	if err != nil {
+		t.Fatalf("ParseKeySet() error = %v", err)
+	}
+	if keys.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", keys.Len())
+	}
+	if len(keys.Skipped()) != 3 {
+		t.Fatalf("Skipped() = %v, want 3 entries", keys.Skipped())
+	}
+
+	if _, err := ParseKeySet([]byte(`{"keys":[{"kty":"oct","kid":"oct","k":"c2VjcmV0"}]}`)); err == nil {
+		t.Fatal("ParseKeySet() without usable keys succeeded, want error")
+	}
+}
@@ -0,0 +1,25 @@
+package network
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+type CountingConn struct {
+	net.Conn
+	bytesWritten atomic.Int64
+}
+
+func NewCountingConn(conn net.Conn) *CountingConn {
+	return &CountingConn{Conn: conn}
+}
+
+func (c *CountingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.bytesWritten.Add(int64(n))
+	return n, err
+}
+
+func (c *CountingConn) BytesWritten() int64 {
+	return c.bytesWritten.Load()
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+const keyedSweepInterval = time.Minute
+
+type KeyedLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     int
+	buckets   map[string]*TokenBucket
+	lastSweep time.Time
+}
+
+func NewKeyedLimiter(rate float64, burst int) *KeyedLimiter {
+	return &KeyedLimiter{
+		rate:      rate,
+		burst:     burst,
+		buckets:   make(map[string]*TokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *KeyedLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= keyedSweepInterval {
+		l.sweepLocked(now)
+	}
+
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = NewTokenBucket(l.rate, l.burst)
+		l.buckets[key] = bucket
+	}
+	l.mu.Unlock()
+
+	return bucket.Allow()
+}
+
+func (l *KeyedLimiter) sweepLocked(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.isFull(now) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}